@@ -2,128 +2,358 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
-	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
+	grpclogging "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/recovery"
+	adminsvc "github.com/knightpp/alias-proto/go/admin_service"
 	gamesvc "github.com/knightpp/alias-proto/go/game_service"
 	loginsvc "github.com/knightpp/alias-proto/go/login_service"
+	"github.com/knightpp/alias-server/internal/adminservice"
+	"github.com/knightpp/alias-server/internal/config"
+	"github.com/knightpp/alias-server/internal/health"
+	"github.com/knightpp/alias-server/internal/logging"
 	"github.com/knightpp/alias-server/internal/loginservice"
+	"github.com/knightpp/alias-server/internal/metrics"
+	"github.com/knightpp/alias-server/internal/ratelimit"
 	"github.com/knightpp/alias-server/internal/server"
 	"github.com/knightpp/alias-server/internal/storage"
 	"github.com/knightpp/alias-server/internal/storage/memory"
 	"github.com/knightpp/alias-server/internal/storage/redis"
+	"github.com/knightpp/alias-server/internal/tlsconfig"
+	"github.com/knightpp/alias-server/internal/tracing"
+	"github.com/knightpp/alias-server/internal/web"
+	"github.com/knightpp/alias-server/internal/wsgateway"
+	"github.com/pires/go-proxyproto"
 	"github.com/rs/zerolog"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"golang.ngrok.com/ngrok"
-	"golang.ngrok.com/ngrok/config"
+	ngrokconfig "golang.ngrok.com/ngrok/config"
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/h2c"
+	"golang.org/x/time/rate"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
 )
 
-var (
-	ngrokFlag     = flag.Bool("ngrok", false, "starts ngrok tunnel")
-	ngrokAuthFlag = flag.String("ngrok-auth", "2Omz9oTCclkfVSwCFf8GBFsDt5E_7rmnvXs7aUePuNh8pGzmc", "auth token for ngrok")
-	addr          string
-	useH2C        bool
-)
-
-func init() {
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+func main() {
+	cfg, err := config.Load(os.Args[0], os.Args[1:], os.LookupEnv)
+	if errors.Is(err, flag.ErrHelp) {
+		return
 	}
-
-	h2c := false
-	if os.Getenv("USE_H2C") == "1" {
-		h2c = true
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
 	}
 
-	flag.StringVar(&addr, "addr", "0.0.0.0:"+port, "addr to listen to")
-	flag.BoolVar(&useH2C, "h2c", h2c, "enables TLS")
-}
+	if cfg.PrintConfig {
+		err := cfg.Print(os.Stdout)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
 
-func main() {
-	flag.Parse()
+	logOpts, err := cfg.Log.Options()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
 
-	log := zerolog.New(zerolog.ConsoleWriter{Out: os.Stderr}).
-		With().
-		Timestamp().
-		Logger().
-		Level(zerolog.TraceLevel)
+	log, err := logging.New(os.Stderr, logOpts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
 
-	if err := run(log); err != nil {
+	if err := run(log, cfg); err != nil {
 		log.Fatal().Err(err).Msg("server failed")
 	}
 }
 
-func run(log zerolog.Logger) error {
-	var playerDB storage.Player
-	if url, ok := os.LookupEnv("REDIS_URL"); ok {
-		pdb, err := redis.NewFromURL(url)
+func run(log zerolog.Logger, cfg config.Config) error {
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Options{
+		Endpoint:    cfg.Tracing.OTLPEndpoint,
+		Insecure:    cfg.Tracing.OTLPInsecure,
+		ServiceName: "alias-server",
+	})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err := shutdownTracing(context.Background())
+		if err != nil {
+			log.Err(err).Msg("shutdown tracing")
+		}
+	}()
+
+	var db storage.Storage
+	if cfg.Redis.URL != "" {
+		pdb, err := redis.NewFromURL(cfg.Redis.URL)
 		if err != nil {
 			return err
 		}
 
-		playerDB = pdb
-	} else if addr, ok := os.LookupEnv("REDIS_ADDR"); ok {
-		playerDB = redis.New(addr)
+		db = pdb
+	} else if cfg.Redis.Addr != "" {
+		db = redis.New(cfg.Redis.Addr)
 	} else {
 		log.Warn().Msg("using inmem storage")
-		playerDB = memory.New()
+		db = memory.New()
 	}
+	db = metrics.InstrumentStorage(db)
 
-	gameServer := server.New(log, playerDB)
+	if cfg.MetricsAddr != "" {
+		go serveMetrics(log, cfg.MetricsAddr)
+	}
 
-	grpcLog := interceptorLogger(log)
-	grpcServer := grpc.NewServer(
-		grpc.ChainStreamInterceptor(
-			logging.StreamServerInterceptor(grpcLog),
-			recovery.StreamServerInterceptor(),
-		),
-		grpc.ChainUnaryInterceptor(
-			logging.UnaryServerInterceptor(grpcLog),
-			recovery.UnaryServerInterceptor(),
-		),
-	)
+	serviceOpts := server.DefaultOptions()
+	serviceOpts.Game.Player.Heartbeat = cfg.Keepalive.Heartbeat
+	serviceOpts.Game.IdleTimeout = cfg.Keepalive.IdleTimeout
+	serviceOpts.Game.Rooms.Max = cfg.Rooms.Max
+	serviceOpts.Game.Rooms.MaxPerPlayer = cfg.Rooms.MaxPerPlayer
+	serviceOpts.Game.Rooms.UnjoinedTTL = cfg.Rooms.UnjoinedTTL
+	serviceOpts.Game.Rooms.IdleTTL = cfg.Rooms.IdleTTL
+	gameServer := server.New(log, db, serviceOpts)
+
+	var reloader *tlsconfig.Reloader
+	grpcLog := logging.Component(log, "grpc")
+	streamInterceptors := []grpc.StreamServerInterceptor{
+		otelgrpc.StreamServerInterceptor(),
+		metrics.StreamServerInterceptor(),
+		logging.StreamServerInterceptor(grpcLog),
+		grpclogging.StreamServerInterceptor(interceptorLogger(grpcLog)),
+		recovery.StreamServerInterceptor(),
+	}
+	unaryInterceptors := []grpc.UnaryServerInterceptor{
+		otelgrpc.UnaryServerInterceptor(),
+		metrics.UnaryServerInterceptor(),
+		logging.UnaryServerInterceptor(grpcLog),
+		grpclogging.UnaryServerInterceptor(interceptorLogger(grpcLog)),
+		recovery.UnaryServerInterceptor(),
+	}
+	if cfg.RateLimit.Rate > 0 {
+		limitOpts := ratelimit.DefaultOptions()
+		limitOpts.Default = ratelimit.Limit{
+			Rate:  rate.Limit(cfg.RateLimit.Rate),
+			Burst: cfg.RateLimit.Burst,
+		}
+		limiter := ratelimit.New(logging.Component(log, "ratelimit"), db, limitOpts)
+
+		// rejected requests are logged and counted, but don't reach
+		// handlers
+		streamInterceptors = append(streamInterceptors, limiter.StreamServerInterceptor())
+		unaryInterceptors = append(unaryInterceptors, limiter.UnaryServerInterceptor())
+	}
+
+	serverOpts := []grpc.ServerOption{
+		grpc.ChainStreamInterceptor(streamInterceptors...),
+		grpc.ChainUnaryInterceptor(unaryInterceptors...),
+		// dead connections of mobile players are closed, so they leave
+		// their rooms
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			Time:    cfg.Keepalive.Time,
+			Timeout: cfg.Keepalive.Timeout,
+		}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             cfg.Keepalive.MinTime,
+			PermitWithoutStream: true,
+		}),
+	}
+	if cfg.TLS.Enabled() {
+		tlsOpts := tlsconfig.DefaultOptions(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		tlsOpts.ClientCAFile = cfg.TLS.ClientCAFile
+		tlsOpts.ReloadInterval = cfg.TLS.Reload
+
+		reloader, err = tlsconfig.NewReloader(logging.Component(log, "tls"), tlsOpts)
+		if err != nil {
+			return err
+		}
+
+		tlsCfg, err := reloader.Config()
+		if err != nil {
+			return err
+		}
+
+		serverOpts = append(serverOpts, grpc.Creds(credentials.NewTLS(tlsCfg)))
+	}
+	grpcServer := grpc.NewServer(serverOpts...)
 	gamesvc.RegisterGameServiceServer(grpcServer, gameServer)
-	loginsvc.RegisterLoginServiceServer(grpcServer, loginservice.New(playerDB))
+	loginsvc.RegisterLoginServiceServer(grpcServer, loginservice.New(db))
+	if cfg.AdminToken != "" || cfg.TLS.ClientCAFile != "" {
+		adminsvc.RegisterAdminServiceServer(grpcServer, adminservice.New(gameServer.Game(), db, adminservice.Options{
+			Token:             cfg.AdminToken,
+			RequireClientCert: cfg.TLS.ClientCAFile != "",
+		}))
+	} else {
+		log.Info().Msg("admin service is disabled, neither admin token nor tls client CA is set")
+	}
 
-	log.Info().Str("addr", addr).Msg("starting GRPC server")
+	checker := health.New(log, db, health.DefaultOptions(
+		gamesvc.GameService_ServiceDesc.ServiceName,
+		loginsvc.LoginService_ServiceDesc.ServiceName,
+	))
+	healthpb.RegisterHealthServer(grpcServer, checker.Server())
 
-	if !useH2C {
-		lis, err := listen(log, addr)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go checker.Start(ctx)
+	go gameServer.Start(ctx)
+	if reloader != nil {
+		go reloader.Start(ctx)
+	}
+
+	log.Info().Str("addr", cfg.Addr).Bool("tls", cfg.TLS.Enabled()).Msg("starting GRPC server")
+
+	var (
+		serveErr   = make(chan error, 1)
+		httpServer *http.Server
+	)
+	if !cfg.H2C {
+		if cfg.HealthAddr != "" {
+			go serveHealth(log, cfg.HealthAddr, checker)
+		}
+
+		lis, err := listen(log, cfg.Addr, cfg.Ngrok)
 		if err != nil {
 			return err
 		}
+		if cfg.ProxyProtocol {
+			lis = &proxyproto.Listener{Listener: lis}
+		}
+
+		go func() {
+			serveErr <- grpcServer.Serve(lis)
+		}()
+	} else {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/healthz", checker.Healthz)
+		mux.HandleFunc("/readyz", checker.Readyz)
+		origins := web.ParseOrigins(cfg.Web.Origins)
+
+		wsOpts := wsgateway.DefaultOptions()
+		wsOpts.AllowedOrigins = origins
+		wsOpts.Interceptors = streamInterceptors
+		mux.Handle("/ws/join", wsgateway.New(logging.Component(log, "wsgateway"), gameServer.Join, wsOpts))
+		mux.Handle("/", web.Handler(grpcServer, web.Options{
+			AllowedOrigins: origins,
+		}))
+
+		lis, err := net.Listen("tcp", cfg.Addr)
+		if err != nil {
+			return fmt.Errorf("listen socket: %w", err)
+		}
+		if cfg.ProxyProtocol {
+			lis = &proxyproto.Listener{Listener: lis}
+		}
+
+		httpServer = &http.Server{
+			Handler: h2c.NewHandler(mux, &http2.Server{}),
+		}
+		go func() {
+			serveErr <- httpServer.Serve(lis)
+		}()
+	}
 
-		return grpcServer.Serve(lis)
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
 	}
 
+	// stop receiving new players, then let the current ones finish, all
+	// within the drain time
+	log.Info().Dur("drain", cfg.Drain).Msg("draining")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Drain)
+	defer cancel()
+
+	checker.Drain()
+	time.Sleep(cfg.Drain / 2)
+
+	if httpServer != nil {
+		err := httpServer.Shutdown(shutdownCtx)
+		if err != nil {
+			log.Err(err).Msg("shutdown http server")
+		}
+	}
+	gracefulStop(shutdownCtx, grpcServer)
+
+	log.Info().Msg("server stopped")
+	return nil
+}
+
+// gracefulStop waits for RPCs to finish until ctx is done and then cancels
+// the rest, e.g. Join streams of players who are still in rooms.
+func gracefulStop(ctx context.Context, s *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.Stop()
+	}
+}
+
+// serveHealth serves HTTP health checks for load balancers that can't
+// check gRPC health.
+func serveHealth(log zerolog.Logger, addr string, checker *health.Checker) {
 	mux := http.NewServeMux()
-	mux.Handle("/", http.HandlerFunc(grpcServer.ServeHTTP))
-	return http.ListenAndServe(
-		addr,
-		h2c.NewHandler(mux, &http2.Server{}),
-	)
+	mux.HandleFunc("/healthz", checker.Healthz)
+	mux.HandleFunc("/readyz", checker.Readyz)
+
+	log.Info().Str("addr", addr).Msg("starting health server")
+
+	err := http.ListenAndServe(addr, mux)
+	if err != nil {
+		log.Err(err).Msg("health server failed")
+	}
 }
 
-func interceptorLogger(l zerolog.Logger) logging.Logger {
-	return logging.LoggerFunc(func(ctx context.Context, lvl logging.Level, msg string, fields ...any) {
+func serveMetrics(log zerolog.Logger, addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+
+	log.Info().Str("addr", addr).Msg("starting metrics server")
+
+	err := http.ListenAndServe(addr, mux)
+	if err != nil {
+		log.Err(err).Msg("metrics server failed")
+	}
+}
+
+// interceptorLogger prefers the logger in ctx, which has ID of the request.
+func interceptorLogger(log zerolog.Logger) grpclogging.Logger {
+	return grpclogging.LoggerFunc(func(ctx context.Context, lvl grpclogging.Level, msg string, fields ...any) {
+		l := log
+		if ctxLog := zerolog.Ctx(ctx); ctxLog.GetLevel() != zerolog.Disabled {
+			l = *ctxLog
+		}
 		l = l.With().Fields(fields).Logger()
 
 		switch lvl {
-		case logging.LevelDebug:
+		case grpclogging.LevelDebug:
 			l.Debug().Msg(msg)
-		case logging.LevelInfo:
+		case grpclogging.LevelInfo:
 			l.Info().Msg(msg)
-		case logging.LevelWarn:
+		case grpclogging.LevelWarn:
 			l.Warn().Msg(msg)
-		case logging.LevelError:
+		case grpclogging.LevelError:
 			l.Error().Msg(msg)
 		default:
 			panic(fmt.Sprintf("unknown level %v", lvl))
@@ -131,13 +361,13 @@ func interceptorLogger(l zerolog.Logger) logging.Logger {
 	})
 }
 
-func listen(log zerolog.Logger, addr string) (net.Listener, error) {
-	if *ngrokFlag {
+func listen(log zerolog.Logger, addr string, ngrokCfg config.Ngrok) (net.Listener, error) {
+	if ngrokCfg.Enabled {
 		log.Info().Msg("using ngrok")
 		tun, err := ngrok.Listen(
 			context.Background(),
-			config.TCPEndpoint(),
-			ngrok.WithAuthtoken(*ngrokAuthFlag),
+			ngrokconfig.TCPEndpoint(),
+			ngrok.WithAuthtoken(ngrokCfg.AuthToken),
 		)
 		if err != nil {
 			return nil, fmt.Errorf("create ngrok tunnel: %w", err)
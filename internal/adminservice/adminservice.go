@@ -0,0 +1,222 @@
+// Package adminservice lets operators inspect and intervene in live games.
+package adminservice
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+
+	adminsvc "github.com/knightpp/alias-proto/go/admin_service"
+	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	"github.com/knightpp/alias-proto/go/mdkey"
+	"github.com/knightpp/alias-server/internal/game"
+	"github.com/knightpp/alias-server/internal/storage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+var _ adminsvc.AdminServiceServer = (*AdminService)(nil)
+
+type AdminService struct {
+	adminsvc.UnimplementedAdminServiceServer
+
+	game *game.Game
+	db   storage.Player
+	opts Options
+}
+
+// Options configure how requests are authorized, at least one of them must
+// be set.
+type Options struct {
+	// Token is required in metadata of requests if it's not empty.
+	Token string
+	// RequireClientCert requires a verified TLS client certificate.
+	RequireClientCert bool
+}
+
+func New(g *game.Game, db storage.Player, opts Options) *AdminService {
+	return &AdminService{
+		game: g,
+		db:   db,
+		opts: opts,
+	}
+}
+
+func (a *AdminService) ListRooms(
+	ctx context.Context,
+	_ *adminsvc.ListRoomsRequest,
+) (*adminsvc.ListRoomsResponse, error) {
+	err := a.authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	descriptions := a.game.DescribeRooms()
+
+	resp := &adminsvc.ListRoomsResponse{
+		Rooms: make([]*adminsvc.RoomState, len(descriptions)),
+	}
+	for i, desc := range descriptions {
+		state := &adminsvc.RoomState{
+			Room:          desc.Room,
+			Password:      desc.Password,
+			Reservations:  desc.Reservations,
+			State:         desc.State.State,
+			PlayerIdTurn:  desc.State.PlayerIDTurn,
+			TeamIdToStats: desc.State.Stats,
+		}
+		if !desc.State.TurnDeadline.IsZero() {
+			state.TurnDeadlineMs = desc.State.TurnDeadline.UnixMilli()
+		}
+
+		resp.Rooms[i] = state
+	}
+
+	return resp, nil
+}
+
+func (a *AdminService) CloseRoom(
+	ctx context.Context,
+	req *adminsvc.CloseRoomRequest,
+) (*adminsvc.CloseRoomResponse, error) {
+	err := a.authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = a.game.CloseRoom(req.RoomId, req.Reason)
+	if err != nil {
+		return nil, gameError(err)
+	}
+
+	return &adminsvc.CloseRoomResponse{}, nil
+}
+
+func (a *AdminService) KickPlayer(
+	ctx context.Context,
+	req *adminsvc.KickPlayerRequest,
+) (*adminsvc.KickPlayerResponse, error) {
+	err := a.authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = a.game.KickPlayer(req.RoomId, req.PlayerId, req.Reason)
+	if err != nil {
+		return nil, gameError(err)
+	}
+
+	return &adminsvc.KickPlayerResponse{}, nil
+}
+
+// BroadcastNotice sends a notice to players in the room or in every room.
+func (a *AdminService) BroadcastNotice(
+	ctx context.Context,
+	req *adminsvc.BroadcastNoticeRequest,
+) (*adminsvc.BroadcastNoticeResponse, error) {
+	err := a.authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Text == "" {
+		return nil, status.Error(codes.InvalidArgument, "notice is empty")
+	}
+
+	sent, err := a.game.Broadcast(req.GetRoomId(), req.Text)
+	if err != nil {
+		return nil, gameError(err)
+	}
+
+	return &adminsvc.BroadcastNoticeResponse{
+		Players: uint32(sent),
+	}, nil
+}
+
+// GetPlayer looks up a player by token or ID, and the room they are in.
+func (a *AdminService) GetPlayer(
+	ctx context.Context,
+	req *adminsvc.GetPlayerRequest,
+) (*adminsvc.GetPlayerResponse, error) {
+	err := a.authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var player *gamesvc.Player
+	switch key := req.Key.(type) {
+	case *adminsvc.GetPlayerRequest_Token:
+		player, err = a.db.GetPlayer(ctx, key.Token)
+	case *adminsvc.GetPlayerRequest_PlayerId:
+		player, err = a.db.GetPlayerByID(ctx, key.PlayerId)
+	default:
+		return nil, status.Error(codes.InvalidArgument, "token or player id is required")
+	}
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		return nil, status.Error(codes.NotFound, err.Error())
+	case err != nil:
+		return nil, fmt.Errorf("get player: %w", err)
+	}
+
+	resp := &adminsvc.GetPlayerResponse{
+		Player: player,
+	}
+	if roomID, ok := a.game.FindPlayers([]string{player.Id})[player.Id]; ok {
+		resp.RoomId = &roomID
+	}
+
+	return resp, nil
+}
+
+func (a *AdminService) authorize(ctx context.Context) error {
+	if a.opts.RequireClientCert && !hasClientCert(ctx) {
+		return status.Error(codes.Unauthenticated, "client certificate is required")
+	}
+
+	if a.opts.Token == "" {
+		return nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	tokens := md.Get(mdkey.AdminAuth)
+	if len(tokens) != 1 {
+		return status.Error(codes.Unauthenticated, "admin token is required")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(tokens[0]), []byte(a.opts.Token)) != 1 {
+		return status.Error(codes.PermissionDenied, "invalid admin token")
+	}
+
+	return nil
+}
+
+func hasClientCert(ctx context.Context) bool {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return false
+	}
+
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return false
+	}
+
+	return len(info.State.VerifiedChains) > 0
+}
+
+func gameError(err error) error {
+	switch {
+	case errors.Is(err, game.ErrRoomNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, game.ErrPlayerNotInRoom):
+		return status.Error(codes.NotFound, err.Error())
+	default:
+		return err
+	}
+}
@@ -0,0 +1,153 @@
+// Package cheat detects explainers who give away the word they explain.
+package cheat
+
+import (
+	"strings"
+	"unicode"
+)
+
+// minRootLength is the shortest root that is matched as a prefix of other
+// words. Shorter roots match too many unrelated words.
+const minRootLength = 4
+
+// suffixes are common endings per room language, the longest first.
+var suffixes = map[string][]string{
+	"EN": {"ing", "est", "ed", "es", "er", "ly", "s"},
+	"UA": {
+		"ами", "ями", "ові", "еві", "ах", "ях", "ом", "ем", "ою", "ею", "ів", "ей",
+		"ий", "ій", "а", "я", "о", "е", "и", "і", "у", "ю", "ь", "й",
+	},
+	"RU": {
+		"ами", "ями", "ов", "ев", "ах", "ях", "ом", "ем", "ой", "ей", "ий", "ый",
+		"ая", "ое", "а", "я", "о", "е", "ы", "и", "у", "ю", "ь", "й",
+	},
+}
+
+var translit = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "h", 'ґ': "g", 'д': "d", 'е': "e",
+	'є': "ie", 'ж': "zh", 'з': "z", 'и': "y", 'і': "i", 'ї': "i", 'й': "i",
+	'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r",
+	'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch",
+	'ш': "sh", 'щ': "shch", 'ь': "", 'ю': "iu", 'я': "ia", 'ё': "e",
+	'ъ': "", 'ы': "y", 'э': "e",
+}
+
+var latinVariants = strings.NewReplacer(
+	"ia", "ya", "ja", "ya",
+	"iu", "yu", "ju", "yu",
+	"ie", "ye", "je", "ye",
+	"j", "y",
+)
+
+// Detector checks text of the explainer against the word.
+type Detector struct{}
+
+func New() Detector {
+	return Detector{}
+}
+
+// Leaks reports whether the text contains the word, a word with the same
+// root or the word written in Latin letters. Spelling the word letter by
+// letter doesn't hide it.
+func (Detector) Leaks(language, word, text string) bool {
+	word = normalize(word)
+	if word == "" {
+		return false
+	}
+
+	wordLatin := transliterate(word)
+	root := transliterate(stem(language, word))
+
+	for _, token := range joinSpelled(strings.Fields(normalize(text))) {
+		tokenLatin := transliterate(token)
+
+		switch {
+		case tokenLatin == wordLatin:
+			return true
+		case transliterate(stem(language, token)) == root:
+			return true
+		case len(root) >= minRootLength && strings.HasPrefix(tokenLatin, root):
+			return true
+		case len(wordLatin) >= minRootLength && strings.Contains(tokenLatin, wordLatin):
+			return true
+		}
+	}
+
+	return false
+}
+
+// joinSpelled joins runs of single letters, so "a p p l e" becomes "apple".
+func joinSpelled(tokens []string) []string {
+	var (
+		joined  []string
+		spelled strings.Builder
+	)
+	flush := func() {
+		if spelled.Len() != 0 {
+			joined = append(joined, spelled.String())
+			spelled.Reset()
+		}
+	}
+
+	for _, token := range tokens {
+		if len([]rune(token)) == 1 {
+			spelled.WriteString(token)
+			continue
+		}
+
+		flush()
+		joined = append(joined, token)
+	}
+	flush()
+
+	return joined
+}
+
+// normalize lowercases the text and keeps only letters and digits of words.
+// Apostrophes inside words are dropped.
+func normalize(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case r == '\'' || r == '’' || r == 'ʼ' || r == '`':
+			continue
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		default:
+			b.WriteRune(' ')
+		}
+	}
+	return b.String()
+}
+
+// stem cuts a common ending of the language off the word. The rest must be
+// at least minRootLength-1 letters long.
+func stem(language, word string) string {
+	runes := len([]rune(word))
+	for _, suffix := range suffixes[strings.ToUpper(language)] {
+		if !strings.HasSuffix(word, suffix) {
+			continue
+		}
+
+		if runes-len([]rune(suffix)) >= minRootLength-1 {
+			return strings.TrimSuffix(word, suffix)
+		}
+	}
+	return word
+}
+
+// transliterate writes Cyrillic letters in Latin. The result is simplified,
+// so that common ways to write a letter, like "ya", "ia" and "ja" for "я",
+// are the same.
+func transliterate(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		latin, ok := translit[r]
+		if ok {
+			b.WriteString(latin)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return latinVariants.Replace(b.String())
+}
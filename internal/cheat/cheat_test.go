@@ -0,0 +1,59 @@
+package cheat_test
+
+import (
+	"testing"
+
+	"github.com/knightpp/alias-server/internal/cheat"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLeaks(t *testing.T) {
+	tests := []struct {
+		name     string
+		language string
+		word     string
+		text     string
+		want     bool
+	}{
+		// matches
+		{name: "the word", language: "EN", word: "apple", text: "it's an apple", want: true},
+		{name: "case and punctuation", language: "EN", word: "apple", text: "APPLE!", want: true},
+		{name: "another form", language: "EN", word: "apple", text: "apples are red", want: true},
+		{name: "inside another word", language: "EN", word: "apple", text: "pineapple", want: true},
+		{name: "spelled", language: "EN", word: "apple", text: "it is a p p l e", want: true},
+		{name: "spelled with punctuation", language: "EN", word: "apple", text: "a-p-p-l-e", want: true},
+		{name: "same root", language: "EN", word: "jumping", text: "he jumped", want: true},
+		{name: "ukrainian form", language: "UA", word: "кішка", text: "дві кішки", want: true},
+		{name: "ukrainian in latin", language: "UA", word: "кішка", text: "kishka", want: true},
+		{name: "ukrainian apostrophe", language: "UA", word: "м'ясо", text: "мʼясо", want: true},
+		{name: "russian form", language: "RU", word: "собака", text: "много собак", want: true},
+		{name: "russian in latin", language: "RU", word: "собака", text: "sobaka", want: true},
+		{name: "latin variants", language: "UA", word: "яблуко", text: "jabluko", want: true},
+
+		// near misses
+		{name: "shorter word", language: "EN", word: "apple", text: "download the app", want: false},
+		{name: "similar word", language: "EN", word: "apple", text: "applause", want: false},
+		{name: "ukrainian similar word", language: "UA", word: "кішка", text: "кіт", want: false},
+		{name: "russian similar word", language: "RU", word: "собака", text: "собор", want: false},
+		{name: "unrelated text", language: "EN", word: "apple", text: "a red fruit", want: false},
+		{name: "unknown language", language: "DE", word: "apfel", text: "der baum", want: false},
+
+		// short words
+		{name: "short word", language: "EN", word: "cat", text: "a cat", want: true},
+		{name: "short word form", language: "EN", word: "cat", text: "two cats", want: true},
+		{name: "short word spelled", language: "EN", word: "cat", text: "c a t", want: true},
+		{name: "short word as prefix", language: "EN", word: "cat", text: "category", want: false},
+		{name: "short word inside", language: "EN", word: "cat", text: "concatenate", want: false},
+		{name: "short ukrainian word", language: "UA", word: "кіт", text: "кіт", want: true},
+		{name: "short ukrainian word as prefix", language: "UA", word: "кіт", text: "кітель", want: false},
+
+		{name: "empty word", language: "EN", word: "", text: "anything", want: false},
+		{name: "empty text", language: "EN", word: "apple", text: "", want: false},
+	}
+	detector := cheat.New()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, detector.Leaks(tt.language, tt.word, tt.text))
+		})
+	}
+}
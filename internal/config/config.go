@@ -0,0 +1,440 @@
+// Package config loads configuration of the server. Flags take precedence
+// over environment variables, which take precedence over the config file,
+// which takes precedence over defaults.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/knightpp/alias-server/internal/logging"
+	"github.com/rs/zerolog"
+	"gopkg.in/yaml.v3"
+)
+
+type Config struct {
+	Addr string `yaml:"addr"`
+	H2C  bool   `yaml:"h2c"`
+	// ProxyProtocol reads addresses of clients from PROXY protocol headers
+	// sent by a load balancer, e.g. fly.io.
+	ProxyProtocol bool   `yaml:"proxy_protocol"`
+	MetricsAddr   string `yaml:"metrics_addr"`
+	// HealthAddr serves HTTP health checks if gRPC is served without h2c,
+	// with h2c they are served on Addr.
+	HealthAddr string `yaml:"health_addr"`
+	// Drain is the time to stop the server: the first half of it the
+	// server reports not ready, in the rest RPCs finish.
+	Drain      time.Duration `yaml:"drain"`
+	AdminToken string        `yaml:"admin_token"`
+
+	TLS       TLS       `yaml:"tls"`
+	Web       Web       `yaml:"web"`
+	Keepalive Keepalive `yaml:"keepalive"`
+	Rooms     Rooms     `yaml:"rooms"`
+	RateLimit RateLimit `yaml:"rate_limit"`
+	Redis     Redis     `yaml:"redis"`
+	Ngrok     Ngrok     `yaml:"ngrok"`
+	Tracing   Tracing   `yaml:"tracing"`
+	Log       Log       `yaml:"log"`
+
+	// File and PrintConfig are set only by flags and the environment.
+	File        string `yaml:"-"`
+	PrintConfig bool   `yaml:"-"`
+}
+
+// TLS is used if CertFile and KeyFile are set, otherwise gRPC is served in
+// plaintext.
+type TLS struct {
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+	// ClientCAFile enables client certificates, which are required by the
+	// admin service.
+	ClientCAFile string        `yaml:"client_ca_file"`
+	Reload       time.Duration `yaml:"reload"`
+}
+
+func (t TLS) Enabled() bool {
+	return t.CertFile != "" || t.KeyFile != ""
+}
+
+// Web configures gRPC-Web, which is served with h2c.
+type Web struct {
+	// Origins allowed to make cross-origin requests, e.g.
+	// "https://a.com,https://b.com", "*" allows any origin.
+	Origins string `yaml:"origins"`
+}
+
+// Keepalive detects connections of players that are dead or idle.
+type Keepalive struct {
+	// Time without activity after which the server pings the client over
+	// HTTP/2, and Timeout to wait for the answer before closing the
+	// connection.
+	Time    time.Duration `yaml:"time"`
+	Timeout time.Duration `yaml:"timeout"`
+	// MinTime is the minimum interval of pings from clients, clients that
+	// ping more often are disconnected.
+	MinTime time.Duration `yaml:"min_time"`
+	// Heartbeat is how often players who send nothing are pinged in the
+	// Join stream, zero disables pings.
+	Heartbeat time.Duration `yaml:"heartbeat"`
+	// IdleTimeout kicks players who sent nothing for it from lobbies, zero
+	// disables kicking.
+	IdleTimeout time.Duration `yaml:"idle_timeout"`
+}
+
+// Rooms limits number of rooms and how long unused rooms live.
+type Rooms struct {
+	// Max and MaxPerPlayer are unlimited if zero.
+	Max          int `yaml:"max"`
+	MaxPerPlayer int `yaml:"max_per_player"`
+	// UnjoinedTTL and IdleTTL are disabled if zero.
+	UnjoinedTTL time.Duration `yaml:"unjoined_ttl"`
+	IdleTTL     time.Duration `yaml:"idle_ttl"`
+}
+
+// RateLimit limits RPCs of every player or IP. Some methods, e.g.
+// CreateRoom, have stricter limits.
+type RateLimit struct {
+	// Rate is requests per second, zero disables limits.
+	Rate  float64 `yaml:"rate"`
+	Burst int     `yaml:"burst"`
+}
+
+// Redis is used if URL or Addr is set, URL is preferred.
+type Redis struct {
+	URL  string `yaml:"url"`
+	Addr string `yaml:"addr"`
+}
+
+type Ngrok struct {
+	Enabled   bool   `yaml:"enabled"`
+	AuthToken string `yaml:"auth_token"`
+}
+
+type Tracing struct {
+	OTLPEndpoint string `yaml:"otlp_endpoint"`
+	OTLPInsecure bool   `yaml:"otlp_insecure"`
+}
+
+type Log struct {
+	Format string `yaml:"format"`
+	Level  string `yaml:"level"`
+	Levels string `yaml:"levels"`
+}
+
+func Default() Config {
+	return Config{
+		Addr:        "0.0.0.0:8080",
+		MetricsAddr: "127.0.0.1:9090",
+		HealthAddr:  "0.0.0.0:8081",
+		Drain:       2 * time.Second,
+		TLS: TLS{
+			Reload: 10 * time.Second,
+		},
+		Keepalive: Keepalive{
+			Time:        30 * time.Second,
+			Timeout:     10 * time.Second,
+			MinTime:     10 * time.Second,
+			Heartbeat:   15 * time.Second,
+			IdleTimeout: 2 * time.Minute,
+		},
+		Rooms: Rooms{
+			Max:          1000,
+			MaxPerPlayer: 3,
+			UnjoinedTTL:  5 * time.Minute,
+			IdleTTL:      30 * time.Minute,
+		},
+		RateLimit: RateLimit{
+			Rate:  10,
+			Burst: 20,
+		},
+		Log: Log{
+			Format: string(logging.FormatConsole),
+			Level:  zerolog.InfoLevel.String(),
+		},
+	}
+}
+
+// env maps environment variables to flags they set.
+var env = []struct {
+	name, flag string
+	// value converts the variable to the flag value
+	value func(string) string
+}{
+	{name: "CONFIG_FILE", flag: "config"},
+	{name: "PORT", flag: "addr", value: func(port string) string { return "0.0.0.0:" + port }},
+	{name: "USE_H2C", flag: "h2c", value: isOne},
+	{name: "PROXY_PROTOCOL", flag: "proxy-protocol", value: isOne},
+	{name: "METRICS_PORT", flag: "metrics-addr", value: func(port string) string { return "0.0.0.0:" + port }},
+	{name: "HEALTH_PORT", flag: "health-addr", value: func(port string) string { return "0.0.0.0:" + port }},
+	{name: "ADMIN_TOKEN", flag: "admin-token"},
+	{name: "TLS_CERT_FILE", flag: "tls-cert"},
+	{name: "TLS_KEY_FILE", flag: "tls-key"},
+	{name: "TLS_CLIENT_CA_FILE", flag: "tls-client-ca"},
+	{name: "WEB_ORIGINS", flag: "web-origins"},
+	{name: "IDLE_TIMEOUT", flag: "idle-timeout"},
+	{name: "MAX_ROOMS", flag: "max-rooms"},
+	{name: "RATE_LIMIT", flag: "rate-limit"},
+	{name: "REDIS_URL", flag: "redis-url"},
+	{name: "REDIS_ADDR", flag: "redis-addr"},
+	{name: "NGROK_AUTHTOKEN", flag: "ngrok-auth"},
+	{name: "OTLP_ENDPOINT", flag: "otlp-endpoint"},
+	{name: "LOG_FORMAT", flag: "log-format"},
+	{name: "LOG_LEVEL", flag: "log-level"},
+	{name: "LOG_LEVELS", flag: "log-levels"},
+}
+
+// isOne converts a variable that is enabled only by "1", e.g. USE_H2C, to
+// a bool flag.
+func isOne(value string) string {
+	return strconv.FormatBool(value == "1")
+}
+
+func bind(fs *flag.FlagSet, c *Config) {
+	fs.StringVar(&c.File, "config", c.File, "path to YAML config file")
+	fs.BoolVar(&c.PrintConfig, "print-config", c.PrintConfig, "prints config with secrets redacted and exits")
+
+	fs.StringVar(&c.Addr, "addr", c.Addr, "addr to listen to")
+	fs.BoolVar(&c.H2C, "h2c", c.H2C, "serves gRPC over HTTP/2 without TLS, gRPC-Web and health checks over HTTP")
+	fs.BoolVar(&c.ProxyProtocol, "proxy-protocol", c.ProxyProtocol, "reads addresses of clients from PROXY protocol headers, enable only behind a load balancer that sends them")
+	fs.StringVar(&c.MetricsAddr, "metrics-addr", c.MetricsAddr, "addr to serve metrics on, empty disables metrics")
+	fs.StringVar(&c.HealthAddr, "health-addr", c.HealthAddr, "addr to serve /healthz and /readyz on without -h2c, empty disables them")
+	fs.DurationVar(&c.Drain, "drain", c.Drain, "time to stop the server, half of it is spent reporting not ready and the rest waiting for RPCs to finish")
+	fs.StringVar(&c.AdminToken, "admin-token", c.AdminToken, "token of the admin service, empty disables the service")
+
+	fs.StringVar(&c.TLS.CertFile, "tls-cert", c.TLS.CertFile, "path to PEM certificate, enables TLS with -tls-key")
+	fs.StringVar(&c.TLS.KeyFile, "tls-key", c.TLS.KeyFile, "path to PEM key of the certificate")
+	fs.StringVar(&c.TLS.ClientCAFile, "tls-client-ca", c.TLS.ClientCAFile, "path to PEM CA of client certificates, which are required by the admin service")
+	fs.DurationVar(&c.TLS.Reload, "tls-reload", c.TLS.Reload, "how often the certificate is reloaded if its files change")
+
+	fs.StringVar(&c.Web.Origins, "web-origins", c.Web.Origins, "origins allowed to make cross-origin gRPC-Web requests, e.g. https://a.com,https://b.com")
+
+	fs.DurationVar(&c.Keepalive.Time, "keepalive-time", c.Keepalive.Time, "time without activity after which clients are pinged over HTTP/2")
+	fs.DurationVar(&c.Keepalive.Timeout, "keepalive-timeout", c.Keepalive.Timeout, "time to wait for the answer to a ping before closing the connection")
+	fs.DurationVar(&c.Keepalive.MinTime, "keepalive-min-time", c.Keepalive.MinTime, "minimum interval of pings from clients, clients that ping more often are disconnected")
+	fs.DurationVar(&c.Keepalive.Heartbeat, "heartbeat", c.Keepalive.Heartbeat, "how often players who send nothing are pinged in the Join stream, 0 disables pings")
+	fs.DurationVar(&c.Keepalive.IdleTimeout, "idle-timeout", c.Keepalive.IdleTimeout, "kicks players who sent nothing for it from lobbies, 0 disables kicking")
+
+	fs.IntVar(&c.Rooms.Max, "max-rooms", c.Rooms.Max, "max number of rooms, 0 is unlimited")
+	fs.IntVar(&c.Rooms.MaxPerPlayer, "max-rooms-per-player", c.Rooms.MaxPerPlayer, "max number of open rooms created by one player, 0 is unlimited")
+	fs.DurationVar(&c.Rooms.UnjoinedTTL, "room-unjoined-ttl", c.Rooms.UnjoinedTTL, "closes rooms nobody joined for it, 0 keeps them")
+	fs.DurationVar(&c.Rooms.IdleTTL, "room-idle-ttl", c.Rooms.IdleTTL, "closes rooms where nothing happened for it, 0 keeps them")
+
+	fs.Float64Var(&c.RateLimit.Rate, "rate-limit", c.RateLimit.Rate, "requests per second of every player or IP, 0 disables limits")
+	fs.IntVar(&c.RateLimit.Burst, "rate-burst", c.RateLimit.Burst, "requests allowed at once above -rate-limit")
+
+	fs.StringVar(&c.Redis.URL, "redis-url", c.Redis.URL, "URL of redis, empty uses -redis-addr")
+	fs.StringVar(&c.Redis.Addr, "redis-addr", c.Redis.Addr, "host:port of redis, empty uses in-memory storage")
+
+	fs.BoolVar(&c.Ngrok.Enabled, "ngrok", c.Ngrok.Enabled, "starts ngrok tunnel")
+	fs.StringVar(&c.Ngrok.AuthToken, "ngrok-auth", c.Ngrok.AuthToken, "auth token for ngrok")
+
+	fs.StringVar(&c.Tracing.OTLPEndpoint, "otlp-endpoint", c.Tracing.OTLPEndpoint, "host:port of OTLP gRPC collector, empty disables tracing")
+	fs.BoolVar(&c.Tracing.OTLPInsecure, "otlp-insecure", c.Tracing.OTLPInsecure, "connects to OTLP collector without TLS")
+
+	fs.StringVar(&c.Log.Format, "log-format", c.Log.Format, "format of logs, json or console")
+	fs.StringVar(&c.Log.Level, "log-level", c.Log.Level, "level of logs")
+	fs.StringVar(&c.Log.Levels, "log-levels", c.Log.Levels, "levels of components overriding -log-level, e.g. game=debug,grpc=warn")
+}
+
+// Load loads and validates config. It returns flag.ErrHelp if help was
+// requested.
+func Load(name string, args []string, lookupEnv func(string) (string, bool)) (Config, error) {
+	// flags are parsed first to find the config file, and are applied
+	// again after it and the environment
+	var parsed Config
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	bind(flags, &parsed)
+	err := flags.Parse(args)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg := Default()
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	bind(fs, &cfg)
+
+	file := parsed.File
+	if file == "" {
+		file, _ = lookupEnv("CONFIG_FILE")
+	}
+	if file != "" {
+		err := readFile(file, &cfg)
+		if err != nil {
+			return Config{}, err
+		}
+	}
+
+	for _, e := range env {
+		value, ok := lookupEnv(e.name)
+		if !ok {
+			continue
+		}
+		if e.value != nil {
+			value = e.value(value)
+		}
+
+		err := fs.Set(e.flag, value)
+		if err != nil {
+			return Config{}, fmt.Errorf("env %s: %w", e.name, err)
+		}
+	}
+
+	flags.Visit(func(f *flag.Flag) {
+		// the value was already parsed once
+		_ = fs.Set(f.Name, f.Value.String())
+	})
+
+	err = cfg.Validate()
+	if err != nil {
+		return Config{}, err
+	}
+
+	return cfg, nil
+}
+
+func readFile(path string, cfg *Config) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open config: %w", err)
+	}
+	defer f.Close()
+
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+
+	err = dec.Decode(cfg)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("decode config %s: %w", path, err)
+	}
+
+	return nil
+}
+
+func (c Config) Validate() error {
+	var errs []error
+	if c.Addr == "" {
+		errs = append(errs, errors.New("addr is required"))
+	}
+	if c.Drain < 0 {
+		errs = append(errs, errors.New("drain must not be negative"))
+	}
+	if c.TLS.Enabled() {
+		if c.TLS.CertFile == "" || c.TLS.KeyFile == "" {
+			errs = append(errs, errors.New("tls certificate and key are required together"))
+		}
+		if c.TLS.Reload <= 0 {
+			errs = append(errs, errors.New("tls reload must be positive"))
+		}
+		if c.H2C {
+			errs = append(errs, errors.New("h2c and tls can't be used together"))
+		}
+		if c.Ngrok.Enabled {
+			errs = append(errs, errors.New("ngrok and tls can't be used together"))
+		}
+	}
+	if c.Web.Origins != "" && !c.H2C {
+		errs = append(errs, errors.New("gRPC-Web is served only with h2c"))
+	}
+	if c.Keepalive.Time <= 0 || c.Keepalive.Timeout <= 0 || c.Keepalive.MinTime <= 0 {
+		errs = append(errs, errors.New("keepalive time, timeout and min time must be positive"))
+	}
+	if c.Keepalive.Heartbeat < 0 || c.Keepalive.IdleTimeout < 0 {
+		errs = append(errs, errors.New("heartbeat and idle timeout must not be negative"))
+	}
+	if c.Keepalive.IdleTimeout > 0 && c.Keepalive.IdleTimeout <= c.Keepalive.Heartbeat {
+		errs = append(errs, errors.New("idle timeout must be longer than heartbeat, otherwise players are kicked before they are pinged"))
+	}
+	if c.Rooms.Max < 0 || c.Rooms.MaxPerPlayer < 0 {
+		errs = append(errs, errors.New("room limits must not be negative"))
+	}
+	if c.Rooms.UnjoinedTTL < 0 || c.Rooms.IdleTTL < 0 {
+		errs = append(errs, errors.New("room TTLs must not be negative"))
+	}
+	if c.RateLimit.Rate < 0 {
+		errs = append(errs, errors.New("rate limit must not be negative"))
+	}
+	if c.RateLimit.Rate > 0 && c.RateLimit.Burst <= 0 {
+		errs = append(errs, errors.New("rate burst must be positive"))
+	}
+	if c.TLS.ClientCAFile != "" && !c.TLS.Enabled() {
+		errs = append(errs, errors.New("tls client CA requires tls certificate"))
+	}
+	if c.Ngrok.Enabled && c.Ngrok.AuthToken == "" {
+		errs = append(errs, errors.New("ngrok auth token is required to start ngrok tunnel"))
+	}
+	if c.Redis.URL != "" {
+		_, err := url.Parse(c.Redis.URL)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("redis url: %w", err))
+		}
+	}
+
+	_, err := c.Log.Options()
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// Options returns options of the root logger.
+func (l Log) Options() (logging.Options, error) {
+	format := logging.Format(l.Format)
+	if format != logging.FormatJSON && format != logging.FormatConsole {
+		return logging.Options{}, fmt.Errorf("unknown log format %q", l.Format)
+	}
+
+	level, err := zerolog.ParseLevel(l.Level)
+	if err != nil {
+		return logging.Options{}, fmt.Errorf("parse log level: %w", err)
+	}
+
+	components, err := logging.ParseComponents(l.Levels)
+	if err != nil {
+		return logging.Options{}, fmt.Errorf("parse log levels: %w", err)
+	}
+
+	return logging.Options{
+		Format:     format,
+		Level:      level,
+		Components: components,
+	}, nil
+}
+
+// Redacted returns a copy of the config with secrets replaced, so that it
+// can be printed.
+func (c Config) Redacted() Config {
+	const redacted = "[REDACTED]"
+
+	if c.AdminToken != "" {
+		c.AdminToken = redacted
+	}
+	if c.Ngrok.AuthToken != "" {
+		c.Ngrok.AuthToken = redacted
+	}
+	if u, err := url.Parse(c.Redis.URL); err == nil {
+		c.Redis.URL = u.Redacted()
+	}
+
+	return c
+}
+
+// Print writes the config with secrets redacted as YAML.
+func (c Config) Print(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+
+	err := enc.Encode(c.Redacted())
+	if err != nil {
+		return err
+	}
+
+	return enc.Close()
+}
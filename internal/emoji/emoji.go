@@ -19,6 +19,7 @@ var (
 
 type Emojier interface {
 	Random() string
+	Contains(r rune) bool
 }
 
 type emojiCategory struct {
@@ -39,12 +40,40 @@ func (cat emojiCategory) Random() string {
 	return string(rune(rnd))
 }
 
+func (cat emojiCategory) Contains(r rune) bool {
+	return int(r) >= cat.begin && int(r) <= cat.end
+}
+
 func Random(categories ...Emojier) string {
 	if len(categories) == 0 {
-		categories = []Emojier{Emoticons, Food, Animals, Expressions}
+		categories = allCategories()
 	}
 
 	cat := categories[rand.Intn(len(categories))]
 
 	return cat.Random()
 }
+
+// IsOneOf reports whether s is a single emoji from one of the categories.
+func IsOneOf(s string, categories ...Emojier) bool {
+	if len(categories) == 0 {
+		categories = allCategories()
+	}
+
+	runes := []rune(s)
+	if len(runes) != 1 {
+		return false
+	}
+
+	for _, cat := range categories {
+		if cat.Contains(runes[0]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func allCategories() []Emojier {
+	return []Emojier{Emoticons, Food, Animals, Expressions}
+}
@@ -0,0 +1,145 @@
+package game
+
+import (
+	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	"github.com/knightpp/alias-server/internal/game/entity"
+	"github.com/knightpp/alias-server/internal/game/statemachine"
+)
+
+// RoomDescription is a room with its internal state for operators.
+type RoomDescription struct {
+	Room         *gamesvc.Room
+	Password     *string
+	Reservations map[string]string
+	State        statemachine.Description
+}
+
+// DescribeRooms returns every room with its internal state.
+func (g *Game) DescribeRooms() []RoomDescription {
+	rooms := g.roomList()
+	descriptions := make([]RoomDescription, 0, len(rooms))
+	for _, r := range rooms {
+		desc := runFn1(r.Room, func(_ *entity.Room) RoomDescription {
+			reservations := make(map[string]string, len(r.Reservations))
+			for playerID, res := range r.Reservations {
+				reservations[playerID] = res.TeamID
+			}
+
+			return RoomDescription{
+				Room:         r.GetProto(),
+				Password:     r.Password,
+				Reservations: reservations,
+				State:        statemachine.Describe(r.state),
+			}
+		})
+		// returns zero value if room was deleted from map
+		if desc.Room == nil {
+			continue
+		}
+
+		descriptions = append(descriptions, desc)
+	}
+
+	return descriptions
+}
+
+// CloseRoom tells players in the room why it's closed and disconnects them.
+func (g *Game) CloseRoom(roomID, reason string) error {
+	g.roomsMu.Lock()
+	r, ok := g.rooms[roomID]
+	g.roomsMu.Unlock()
+	if !ok {
+		return ErrRoomNotFound
+	}
+
+	notified := runFn1(r.Room, func(r *entity.Room) bool {
+		sendNotice(reason, r.GetAllPlayers()...)
+		return true
+	})
+	if !notified {
+		// room was deleted while we were waiting
+		return ErrRoomNotFound
+	}
+
+	r.Cancel()
+	return nil
+}
+
+// KickPlayer tells the player why they are kicked and disconnects them.
+func (g *Game) KickPlayer(roomID, playerID, reason string) error {
+	g.roomsMu.Lock()
+	r, ok := g.rooms[roomID]
+	g.roomsMu.Unlock()
+	if !ok {
+		return ErrRoomNotFound
+	}
+
+	kicked := runFn1(r.Room, func(r *entity.Room) error {
+		for _, p := range r.GetAllPlayers() {
+			if p.ID != playerID {
+				continue
+			}
+
+			sendNotice(reason, p)
+			// the player is removed from the room when their connection ends
+			p.Disconnect()
+			return nil
+		}
+
+		return ErrPlayerNotInRoom
+	})
+
+	return kicked
+}
+
+// Broadcast sends a notice to players in the room, or in every room if
+// roomID is empty. It returns number of players the notice was sent to.
+func (g *Game) Broadcast(roomID, text string) (int, error) {
+	var rooms []*room
+	if roomID == "" {
+		rooms = g.roomList()
+	} else {
+		g.roomsMu.Lock()
+		if r, ok := g.rooms[roomID]; ok {
+			rooms = append(rooms, r)
+		}
+		g.roomsMu.Unlock()
+	}
+
+	if roomID != "" && len(rooms) == 0 {
+		return 0, ErrRoomNotFound
+	}
+
+	var sent int
+	for _, r := range rooms {
+		sent += runFn1(r.Room, func(r *entity.Room) int {
+			return sendNotice(text, r.GetAllPlayers()...)
+		})
+	}
+
+	return sent, nil
+}
+
+// sendNotice returns number of players the notice was sent to.
+func sendNotice(text string, players ...*entity.Player) int {
+	if text == "" {
+		return 0
+	}
+
+	msg := &gamesvc.Message{
+		Message: &gamesvc.Message_ServerNotice{
+			ServerNotice: &gamesvc.MsgServerNotice{
+				Text: text,
+			},
+		},
+	}
+
+	var sent int
+	for _, p := range players {
+		err := p.SendMsg(msg)
+		if err == nil {
+			sent += 1
+		}
+	}
+	return sent
+}
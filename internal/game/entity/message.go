@@ -0,0 +1,13 @@
+package entity
+
+import gamesvc "github.com/knightpp/alias-proto/go/game_service"
+
+// MessageType returns a name of the message field, e.g. "start_turn".
+func MessageType(msg *gamesvc.Message) string {
+	m := msg.ProtoReflect()
+	field := m.WhichOneof(m.Descriptor().Oneofs().ByName("message"))
+	if field == nil {
+		return "none"
+	}
+	return string(field.Name())
+}
@@ -2,43 +2,122 @@ package entity
 
 import (
 	"context"
+	"errors"
+	"sync/atomic"
+	"time"
 
 	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	"github.com/knightpp/alias-server/internal/logging"
+	"github.com/knightpp/alias-server/internal/metrics"
+	"github.com/knightpp/alias-server/internal/tracing"
+	"github.com/knightpp/alias-server/internal/tuple"
 	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
 	"google.golang.org/protobuf/encoding/protojson"
 )
 
+var (
+	ErrSlowConsumer = errors.New("player is too slow to receive messages")
+	ErrDisconnected = errors.New("player is disconnected")
+)
+
+// SlowConsumerPolicy decides what happens to a message when the send queue
+// of the player is full.
+type SlowConsumerPolicy int
+
+const (
+	// SlowConsumerDisconnect disconnects the player, they get the current
+	// state of the room when they join again.
+	SlowConsumerDisconnect SlowConsumerPolicy = iota
+	// SlowConsumerDrop drops the message silently.
+	SlowConsumerDrop
+)
+
+type PlayerOptions struct {
+	// QueueSize is the max number of messages waiting to be sent.
+	QueueSize    int
+	SlowConsumer SlowConsumerPolicy
+	// FlushTimeout bounds sending of queued messages after the player is
+	// disconnected, e.g. the reason of a kick.
+	FlushTimeout time.Duration
+	// Heartbeat is how often the player is pinged if they send nothing,
+	// zero disables pings.
+	Heartbeat time.Duration
+}
+
+func DefaultPlayerOptions() PlayerOptions {
+	return PlayerOptions{
+		QueueSize:    64,
+		SlowConsumer: SlowConsumerDisconnect,
+		FlushTimeout: time.Second,
+		Heartbeat:    15 * time.Second,
+	}
+}
+
 type Player struct {
 	ID          string
 	Name        string
 	GravatarUrl string
 
 	Room *Room
+	// ChatLimiter is created on the first chat message.
+	ChatLimiter *rate.Limiter
+	// ReactionLimiter is created on the first reaction.
+	ReactionLimiter *rate.Limiter
+	// MessageLimiters limit messages by type, they are created on the first
+	// message of the type.
+	MessageLimiters map[string]*rate.Limiter
 
-	msgChan chan *gamesvc.Message
-	socket  gamesvc.GameService_JoinServer
-	log     zerolog.Logger
+	msgChan   chan tuple.T2[context.Context, *gamesvc.Message]
+	outbox    chan tuple.T2[trace.Span, *gamesvc.Message]
+	transport Transport
+	opts      PlayerOptions
+	log       zerolog.Logger
+	// lastSeen is unix nanoseconds of the last received message.
+	lastSeen atomic.Int64
+
+	disconnected context.Context
+	disconnect   context.CancelCauseFunc
 }
 
 func NewPlayer(
 	log zerolog.Logger,
-	socket gamesvc.GameService_JoinServer,
+	transport Transport,
 	proto *gamesvc.Player,
 	room *Room,
+	opts PlayerOptions,
 ) *Player {
-	return &Player{
+	logCtx := log.With().
+		Str("player-id", proto.Id).
+		Str("player-name", proto.Name)
+	if room != nil {
+		logCtx = logCtx.Str("room-id", room.Id)
+	}
+	if requestID := logging.RequestID(transport.Context()); requestID != "" {
+		logCtx = logCtx.Str("request-id", requestID)
+	}
+
+	disconnected, disconnect := context.WithCancelCause(context.Background())
+	p := &Player{
 		ID:          proto.Id,
 		Name:        proto.Name,
 		GravatarUrl: proto.GravatarUrl,
 		Room:        room,
 
-		log: log.With().
-			Str("player-id", proto.Id).
-			Str("player-name", proto.Name).
-			Logger(),
-		socket:  socket,
-		msgChan: make(chan *gamesvc.Message),
+		log:       logCtx.Logger(),
+		transport: transport,
+		opts:      opts,
+		msgChan:   make(chan tuple.T2[context.Context, *gamesvc.Message]),
+		outbox:    make(chan tuple.T2[trace.Span, *gamesvc.Message], opts.QueueSize),
+
+		disconnected: disconnected,
+		disconnect:   disconnect,
 	}
+	p.lastSeen.Store(time.Now().UnixNano())
+	return p
 }
 
 func (p *Player) ToProto() *gamesvc.Player {
@@ -53,30 +132,238 @@ func (p *Player) ToProto() *gamesvc.Player {
 	}
 }
 
+// Start receives messages of the player and sends queued messages to them
+// until the connection is closed, ctx is done or the player is
+// disconnected. Messages queued by then are sent before it returns, unless
+// it takes longer than FlushTimeout.
 func (p *Player) Start(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// messages are not queued once it returns
+	defer p.disconnect(ErrDisconnected)
+
+	// Recv blocks until the client sends something, it's left behind and
+	// returns when the stream ends
+	recvErr := make(chan error, 1)
+	go func() {
+		recvErr <- p.receive(ctx)
+	}()
+
+	done := make(chan struct{})
+	writeErr := make(chan error, 1)
+	go func() {
+		writeErr <- p.write(done)
+	}()
+
+	if p.opts.Heartbeat > 0 {
+		go p.heartbeat(ctx)
+	}
+
+	var err error
+	select {
+	case err = <-recvErr:
+	case err = <-writeErr:
+		return err
+	case <-ctx.Done():
+	case <-p.disconnected.Done():
+		err = context.Cause(p.disconnected)
+		if errors.Is(err, context.Canceled) {
+			err = nil
+		}
+	}
+
+	close(done)
+
+	timer := time.NewTimer(p.opts.FlushTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-writeErr:
+		// the connection may be gone already, errors of the flush are
+		// expected
+	case <-timer.C:
+		p.log.Warn().Msg("could not send queued messages in time")
+	}
+
+	return err
+}
+
+// LastSeen returns when the player sent the last message, or when they
+// joined if they sent nothing.
+func (p *Player) LastSeen() time.Time {
+	return time.Unix(0, p.lastSeen.Load())
+}
+
+// Log returns the logger of the player with IDs of the player, room and
+// request.
+func (p *Player) Log() *zerolog.Logger {
+	return &p.log
+}
+
+// Disconnect ends the connection of the player after queued messages are
+// sent.
+func (p *Player) Disconnect() {
+	p.disconnect(nil)
+}
+
+func (p *Player) receive(ctx context.Context) error {
 	for {
-		msg, err := p.socket.Recv()
+		msg, err := p.transport.Recv()
 		if err != nil {
 			return err
 		}
+		p.lastSeen.Store(time.Now().UnixNano())
 
 		evt := p.log.Debug()
 		if evt.Enabled() {
-			json, _ := protojson.Marshal(msg)
+			json, _ := protojson.Marshal(logging.Redact(msg))
 			evt.RawJSON("msg", json).Msg("received a message")
 		}
 
+		// the span ends once the room takes the message, handling of the
+		// message continues it
+		msgCtx, span := tracing.Tracer().Start(p.transport.Context(), "player.receive", trace.WithAttributes(
+			attribute.String("player.id", p.ID),
+			attribute.String("message.type", MessageType(msg)),
+		))
+
+		// pings are answered without the room, a pong only marks the
+		// player as seen
+		switch m := msg.Message.(type) {
+		case *gamesvc.Message_Ping:
+			_ = p.enqueue(msgCtx, &gamesvc.Message{
+				Message: &gamesvc.Message_Pong{
+					Pong: &gamesvc.MsgPong{Timestamp: m.Ping.Timestamp},
+				},
+			})
+			span.End()
+			continue
+		case *gamesvc.Message_Pong:
+			span.End()
+			continue
+		}
+
 		select {
 		case <-ctx.Done():
+			span.End()
 			return nil
-		case p.msgChan <- msg:
+		case p.msgChan <- tuple.NewT2(msgCtx, msg):
+			span.End()
 			continue
 		}
 	}
 }
 
+// SendMsg queues the message to be sent to the player. It doesn't wait for
+// the player, so a slow player doesn't hold up the room.
 func (p *Player) SendMsg(msg *gamesvc.Message) error {
-	return p.socket.Send(msg)
+	ctx := context.Background()
+	if p.Room != nil {
+		ctx = p.Room.TraceContext()
+	}
+
+	return p.enqueue(ctx, msg)
+}
+
+// enqueue queues the message with a span that is a child of ctx.
+func (p *Player) enqueue(ctx context.Context, msg *gamesvc.Message) error {
+	// the span ends when the message is sent
+	_, span := tracing.Tracer().Start(ctx, "player.send", trace.WithAttributes(
+		attribute.String("player.id", p.ID),
+		attribute.String("message.type", MessageType(msg)),
+	))
+
+	if p.disconnected.Err() != nil {
+		endSpan(span, ErrDisconnected)
+		return ErrDisconnected
+	}
+
+	select {
+	case p.outbox <- tuple.NewT2(span, msg):
+		return nil
+	default:
+	}
+
+	endSpan(span, ErrSlowConsumer)
+
+	if p.opts.SlowConsumer == SlowConsumerDrop {
+		metrics.MessagesDropped.Inc()
+		return nil
+	}
+
+	p.log.Warn().Int("queue_size", p.opts.QueueSize).Msg("disconnecting slow player")
+	metrics.SlowConsumers.Inc()
+	p.disconnect(ErrSlowConsumer)
+
+	return ErrSlowConsumer
+}
+
+// heartbeat pings the player when they send nothing for the heartbeat
+// interval, the answer or any other message marks them as seen.
+func (p *Player) heartbeat(ctx context.Context) {
+	ticker := time.NewTicker(p.opts.Heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if now.Sub(p.LastSeen()) < p.opts.Heartbeat {
+				continue
+			}
+
+			_ = p.enqueue(context.Background(), &gamesvc.Message{
+				Message: &gamesvc.Message_Ping{
+					Ping: &gamesvc.MsgPing{Timestamp: now.UnixMilli()},
+				},
+			})
+		}
+	}
+}
+
+// write sends queued messages until done is closed, then sends what is left
+// in the queue.
+func (p *Player) write(done <-chan struct{}) error {
+	for {
+		select {
+		case item := <-p.outbox:
+			err := p.send(item.A, item.B)
+			if err != nil {
+				return err
+			}
+		case <-done:
+			for {
+				select {
+				case item := <-p.outbox:
+					err := p.send(item.A, item.B)
+					if err != nil {
+						return err
+					}
+				default:
+					return nil
+				}
+			}
+		}
+	}
+}
+
+func (p *Player) send(span trace.Span, msg *gamesvc.Message) error {
+	err := p.transport.Send(msg)
+	if err != nil {
+		metrics.SendErrors.Inc()
+	}
+
+	endSpan(span, err)
+	return err
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
 }
 
 func (p *Player) SendError(err string) error {
@@ -89,6 +376,7 @@ func (p *Player) SendError(err string) error {
 	})
 }
 
-func (p *Player) Chan() <-chan *gamesvc.Message {
+// Chan returns received messages with contexts of their spans.
+func (p *Player) Chan() <-chan tuple.T2[context.Context, *gamesvc.Message] {
 	return p.msgChan
 }
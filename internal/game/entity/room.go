@@ -3,6 +3,7 @@ package entity
 import (
 	"context"
 	"errors"
+	"time"
 
 	gamesvc "github.com/knightpp/alias-proto/go/game_service"
 	"github.com/knightpp/alias-server/internal/fp"
@@ -24,12 +25,31 @@ type Room struct {
 	Password  *string
 	Lobby     []*Player
 	Teams     []*Team
+	// Status is a phase of the game, it's kept by the state machine.
+	Status gamesvc.RoomStatus
+	// Reservations maps ID of a player who hasn't joined yet to a team where
+	// a slot is kept for them.
+	Reservations map[string]Reservation
+	CreatedAt    time.Time
+	// LastActive is when a player last joined, left or sent a message to
+	// the room. It's zero if nobody has joined.
+	LastActive time.Time
 
 	ctx        context.Context
 	cancel     func()
-	allMsgChan chan tuple.T2[*gamesvc.Message, *Player]
+	allMsgChan chan tuple.T3[context.Context, *gamesvc.Message, *Player]
 	actorChan  chan func(*Room)
 	log        zerolog.Logger
+	// traceCtx is a context of the message being handled, messages sent
+	// meanwhile are traced as its children.
+	traceCtx context.Context
+}
+
+// Reservation keeps a slot in a team for a player.
+type Reservation struct {
+	TeamID string
+	// At is when the slot was reserved, reservations expire after a while.
+	At time.Time
 }
 
 func NewRoom(
@@ -43,7 +63,7 @@ func NewRoom(
 		cancel:     cancel,
 		log:        log.With().Str("room-id", roomID).Logger(),
 		actorChan:  make(chan func(*Room)),
-		allMsgChan: make(chan tuple.T2[*gamesvc.Message, *Player]),
+		allMsgChan: make(chan tuple.T3[context.Context, *gamesvc.Message, *Player]),
 
 		Id:        roomID,
 		Name:      req.Name,
@@ -51,6 +71,9 @@ func NewRoom(
 		IsPublic:  req.IsPublic,
 		Langugage: req.Langugage,
 		Password:  req.Password,
+
+		Reservations: make(map[string]Reservation),
+		CreatedAt:    time.Now(),
 	}
 }
 
@@ -83,6 +106,105 @@ func (r *Room) FindTeamWithPlayer(playerID string) (*Team, bool) {
 	return nil, false
 }
 
+// TeamHasSpace reports whether the player can take a slot in the team. Slots
+// reserved for other players are taken.
+func (r *Room) TeamHasSpace(team *Team, playerID string) bool {
+	var taken int
+	for _, p := range []*Player{team.PlayerA, team.PlayerB} {
+		if p != nil && p.ID != playerID {
+			taken += 1
+		}
+	}
+
+	for id, res := range r.Reservations {
+		if res.TeamID == team.ID && id != playerID {
+			taken += 1
+		}
+	}
+
+	return taken < 2
+}
+
+// PlaceReserved puts the player into the team reserved for them. It returns
+// false if there is no reservation.
+func (r *Room) PlaceReserved(p *Player) bool {
+	res, ok := r.Reservations[p.ID]
+	if !ok {
+		return false
+	}
+	delete(r.Reservations, p.ID)
+
+	for _, team := range r.Teams {
+		if team.ID != res.TeamID {
+			continue
+		}
+
+		switch {
+		case team.PlayerA == nil:
+			team.PlayerA = p
+		case team.PlayerB == nil:
+			team.PlayerB = p
+		default:
+			return false
+		}
+
+		return true
+	}
+
+	return false
+}
+
+// ReserveTeam reserves slots in one team for all players who are not in the
+// room yet. It returns false if no team has enough space.
+func (r *Room) ReserveTeam(playerIDs []string) bool {
+	var ids []string
+	for _, id := range playerIDs {
+		if !r.HasPlayer(id) {
+			ids = append(ids, id)
+		}
+		delete(r.Reservations, id)
+	}
+
+	for _, team := range r.Teams {
+		free := 2
+		for _, p := range []*Player{team.PlayerA, team.PlayerB} {
+			if p != nil {
+				free -= 1
+			}
+		}
+		for _, res := range r.Reservations {
+			if res.TeamID == team.ID {
+				free -= 1
+			}
+		}
+
+		if free < len(ids) {
+			continue
+		}
+
+		now := time.Now()
+		for _, id := range ids {
+			r.Reservations[id] = Reservation{TeamID: team.ID, At: now}
+		}
+		return true
+	}
+
+	return false
+}
+
+// ExpireReservations frees slots reserved before the time for players who
+// haven't joined. It returns number of freed slots.
+func (r *Room) ExpireReservations(before time.Time) int {
+	var expired int
+	for playerID, res := range r.Reservations {
+		if res.At.Before(before) {
+			delete(r.Reservations, playerID)
+			expired += 1
+		}
+	}
+	return expired
+}
+
 func (r *Room) GetAllPlayers() []*Player {
 	count := len(r.Lobby)
 	for _, t := range r.Teams {
@@ -120,24 +242,62 @@ func (r *Room) GetProto() *gamesvc.Room {
 	}
 
 	return &gamesvc.Room{
-		Id:        r.Id,
-		Name:      r.Name,
-		LeaderId:  r.LeaderId,
-		IsPublic:  r.IsPublic,
-		Langugage: r.Langugage,
-		Lobby:     lobby,
-		Teams:     teams,
+		Id:          r.Id,
+		Name:        r.Name,
+		LeaderId:    r.LeaderId,
+		IsPublic:    r.IsPublic,
+		Langugage:   r.Langugage,
+		Lobby:       lobby,
+		Teams:       teams,
+		Status:      r.Status,
+		PlayerCount: uint32(r.SeatedCount()),
+		Capacity:    uint32(r.Capacity()),
+		HasPassword: r.Password != nil,
+	}
+}
+
+// Capacity is the number of slots in teams. Players in the lobby don't take
+// slots.
+func (r *Room) Capacity() int {
+	return 2 * len(r.Teams)
+}
+
+// SeatedCount is the number of players who take slots in teams, it never
+// exceeds the capacity.
+func (r *Room) SeatedCount() int {
+	var count int
+	for _, t := range r.Teams {
+		if t.PlayerA != nil {
+			count += 1
+		}
+		if t.PlayerB != nil {
+			count += 1
+		}
 	}
+	return count
 }
 
 func (r *Room) Ctx() context.Context {
 	return r.ctx
 }
 
-func (r *Room) AggregationChan() chan tuple.T2[*gamesvc.Message, *Player] {
+func (r *Room) AggregationChan() chan tuple.T3[context.Context, *gamesvc.Message, *Player] {
 	return r.allMsgChan
 }
 
+// SetTraceContext sets a context of the message being handled. It's reset
+// with nil when handling is done.
+func (r *Room) SetTraceContext(ctx context.Context) {
+	r.traceCtx = ctx
+}
+
+func (r *Room) TraceContext() context.Context {
+	if r.traceCtx == nil {
+		return r.ctx
+	}
+	return r.traceCtx
+}
+
 // Do queues for execution. It's async method.
 func (r *Room) Do(fn func(r *Room)) {
 	select {
@@ -189,20 +349,73 @@ func (r *Room) RemovePlayer(playerID string) bool {
 
 	var changed bool
 	for _, team := range r.Teams {
+		var removed bool
 		if team.PlayerA != nil && team.PlayerA.ID == playerID {
-			changed = true
+			removed = true
 			team.PlayerA = nil
 		}
 
 		if team.PlayerB != nil && team.PlayerB.ID == playerID {
-			changed = true
+			removed = true
 			team.PlayerB = nil
 		}
+
+		// nobody is waiting for the rest of the team anymore
+		if removed && team.PlayerA == nil && team.PlayerB == nil {
+			r.cancelReservations(team.ID)
+		}
+		changed = changed || removed
 	}
 
 	return changed || (oldLobbyLen != newLobbyLen)
 }
 
+// KeepSlot removes the player from their team and reserves the slot, so
+// that the player gets back to the team if they join again. It returns false
+// if the player isn't in a team.
+func (r *Room) KeepSlot(playerID string) bool {
+	team, ok := r.FindTeamWithPlayer(playerID)
+	if !ok {
+		return false
+	}
+
+	if team.PlayerA != nil && team.PlayerA.ID == playerID {
+		team.PlayerA = nil
+	} else {
+		team.PlayerB = nil
+	}
+	r.Reservations[playerID] = Reservation{TeamID: team.ID, At: time.Now()}
+
+	return true
+}
+
+// PassLeadership makes another player the leader, players in teams are
+// preferred to the lobby. It returns false if nobody else is in the room.
+func (r *Room) PassLeadership() bool {
+	var players []*Player
+	for _, t := range r.Teams {
+		players = append(players, t.PlayerA, t.PlayerB)
+	}
+	players = append(players, r.Lobby...)
+
+	for _, p := range players {
+		if p != nil && p.ID != r.LeaderId {
+			r.LeaderId = p.ID
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *Room) cancelReservations(teamID string) {
+	for playerID, res := range r.Reservations {
+		if res.TeamID == teamID {
+			delete(r.Reservations, playerID)
+		}
+	}
+}
+
 func (r *Room) AnnounceChange() error {
 	send := func(p *Player) error {
 		if p == nil {
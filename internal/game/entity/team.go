@@ -7,6 +7,8 @@ import (
 type Team struct {
 	ID   string
 	Name string
+	// Badge is an emoji shown next to a generated name.
+	Badge string
 
 	PlayerA *Player
 	PlayerB *Player
@@ -16,6 +18,7 @@ func (t *Team) ToProto() *gamesvc.Team {
 	return &gamesvc.Team{
 		Id:      t.ID,
 		Name:    t.Name,
+		Badge:   t.Badge,
 		PlayerA: t.PlayerA.ToProto(),
 		PlayerB: t.PlayerB.ToProto(),
 	}
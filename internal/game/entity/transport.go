@@ -0,0 +1,19 @@
+package entity
+
+import (
+	"context"
+
+	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+)
+
+var _ Transport = (gamesvc.GameService_JoinServer)(nil)
+
+// Transport carries messages between a player and the room, e.g. the Join
+// stream, a WebSocket or an in-memory transport of tests.
+type Transport interface {
+	// Context is done when the connection ends.
+	Context() context.Context
+	Send(*gamesvc.Message) error
+	// Recv returns io.EOF when the player closes the connection.
+	Recv() (*gamesvc.Message, error)
+}
@@ -4,14 +4,28 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
+	"time"
 
+	"github.com/brianvoe/gofakeit/v6"
+	"github.com/google/uuid"
 	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	"github.com/knightpp/alias-server/internal/emoji"
 	"github.com/knightpp/alias-server/internal/game/entity"
 	"github.com/knightpp/alias-server/internal/game/statemachine"
+	"github.com/knightpp/alias-server/internal/logging"
+	"github.com/knightpp/alias-server/internal/metrics"
+	"github.com/knightpp/alias-server/internal/ratelimit"
+	"github.com/knightpp/alias-server/internal/storage"
+	"github.com/knightpp/alias-server/internal/tracing"
 	"github.com/knightpp/alias-server/internal/tuple"
 	"github.com/knightpp/alias-server/internal/uuidgen"
 	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -21,86 +35,576 @@ var (
 	ErrRoomIncompleteTeam = entity.ErrStartIncompleteTeam
 	ErrRoomNotFound       = errors.New("room not found")
 	ErrPlayerInRoom       = errors.New("player already in the room")
+	ErrPlayerNotInRoom    = errors.New("player is not in the room")
+	ErrTooManyRooms       = errors.New("too many rooms")
+	ErrTooManyPlayerRooms = errors.New("player has created too many rooms")
+	ErrNoTeamSpace        = errors.New("no team has space for the players")
 )
 
 type Game struct {
-	log zerolog.Logger
+	log        zerolog.Logger
+	env        *statemachine.Env
+	playerOpts entity.PlayerOptions
+	// idleTimeout is zero if idle players are not kicked.
+	idleTimeout time.Duration
+	roomOpts    RoomOptions
+	msgLimits   MessageLimits
 
 	roomsMu sync.Mutex
-	rooms   map[string]*entity.Room
+	rooms   map[string]*room
 }
 
-func New(log zerolog.Logger) *Game {
+// room is a room with its current state. The state is accessed only by
+// functions run by the room.
+type room struct {
+	*entity.Room
+	state statemachine.Stater
+	// creatorID is ID of the player who created the room, it's empty for
+	// matched rooms.
+	creatorID string
+}
+
+// setState moves the room to the next state. It must be called by the room.
+func (rm *room) setState(next statemachine.Stater) {
+	prevName, name := statemachine.Name(rm.state), statemachine.Name(next)
+	if name != prevName {
+		metrics.RoomsByState.WithLabelValues(prevName).Dec()
+		metrics.RoomsByState.WithLabelValues(name).Inc()
+	}
+
+	rm.state = next
+	rm.Status = statemachine.Status(next)
+}
+
+// RoomOptions limit number of rooms and how long unused rooms live.
+type RoomOptions struct {
+	// Max is the max number of rooms, zero is unlimited. Only rooms created
+	// by players are limited, matched rooms are always created.
+	Max int
+	// MaxPerPlayer is the max number of open rooms created by one player,
+	// zero is unlimited.
+	MaxPerPlayer int
+	// UnjoinedTTL closes rooms nobody joined for it, zero disables it.
+	UnjoinedTTL time.Duration
+	// IdleTTL closes rooms where nobody joined, left or sent a message for
+	// it, zero disables it.
+	IdleTTL time.Duration
+	// ReservationTTL frees team slots reserved for players who haven't
+	// joined for it, zero disables it.
+	ReservationTTL time.Duration
+	// JanitorInterval is how often rooms are checked for TTLs.
+	JanitorInterval time.Duration
+}
+
+func DefaultRoomOptions() RoomOptions {
+	return RoomOptions{
+		Max:             1000,
+		MaxPerPlayer:    3,
+		UnjoinedTTL:     5 * time.Minute,
+		IdleTTL:         30 * time.Minute,
+		ReservationTTL:  2 * time.Minute,
+		JanitorInterval: 30 * time.Second,
+	}
+}
+
+type Options struct {
+	Chat      statemachine.ChatOptions
+	Cheat     statemachine.CheatOptions
+	Reactions statemachine.ReactionOptions
+	Player    entity.PlayerOptions
+	// IdleTimeout kicks players who sent nothing for it from lobbies, zero
+	// disables kicking.
+	IdleTimeout time.Duration
+	Rooms       RoomOptions
+	Messages    MessageLimits
+}
+
+// MessageLimits limit how often a player can send messages to the room.
+// Chat messages and reactions also have their own limits.
+type MessageLimits struct {
+	// Default limits every type of message without its own limit.
+	Default ratelimit.Limit
+	// Types limits messages by type, e.g. "create_team".
+	Types map[string]ratelimit.Limit
+}
+
+func DefaultMessageLimits() MessageLimits {
+	return MessageLimits{
+		Default: ratelimit.Limit{Rate: 10, Burst: 20},
+		Types: map[string]ratelimit.Limit{
+			"create_team": ratelimit.Every(5*time.Second, 3),
+			"join_team":   ratelimit.Every(time.Second, 5),
+		},
+	}
+}
+
+func DefaultOptions() Options {
+	return Options{
+		Chat:        statemachine.DefaultChatOptions(),
+		Cheat:       statemachine.DefaultCheatOptions(),
+		Reactions:   statemachine.DefaultReactionOptions(),
+		Player:      entity.DefaultPlayerOptions(),
+		IdleTimeout: 2 * time.Minute,
+		Rooms:       DefaultRoomOptions(),
+		Messages:    DefaultMessageLimits(),
+	}
+}
+
+func New(log zerolog.Logger, db storage.Storage, opts Options) *Game {
+	log = logging.Component(log, "game")
+
 	return &Game{
-		log:   log,
-		rooms: make(map[string]*entity.Room),
+		log: log,
+		env: &statemachine.Env{
+			Recorder:  newMatchRecorder(log, db),
+			Chat:      opts.Chat,
+			Cheat:     opts.Cheat,
+			Reactions: opts.Reactions,
+		},
+		playerOpts:  opts.Player,
+		idleTimeout: opts.IdleTimeout,
+		roomOpts:    opts.Rooms,
+		msgLimits:   opts.Messages,
+		rooms:       make(map[string]*room),
 	}
 }
 
+// CreateRoom returns ErrTooManyRooms or ErrTooManyPlayerRooms if limits of
+// rooms are reached.
 func (g *Game) CreateRoom(
 	leader *gamesvc.Player,
 	req *gamesvc.CreateRoomRequest,
+) (roomID string, err error) {
+	r := entity.NewRoom(g.log, uuidgen.NewString(), leader.Id, req)
+	err = g.startRoom(r, leader.Id)
+	if err != nil {
+		return "", err
+	}
+	return r.Id, nil
+}
+
+// CreateMatchedRoom creates a room with a team for every group of players.
+// Players are put into their teams when they join.
+func (g *Game) CreateMatchedRoom(
+	req *gamesvc.CreateRoomRequest,
+	teams [][]*gamesvc.Player,
 ) (roomID string) {
-	roomID = uuidgen.NewString()
-	r := entity.NewRoom(g.log, roomID, leader.Id, req)
+	r := entity.NewRoom(g.log, uuidgen.NewString(), teams[0][0].Id, req)
+	for _, players := range teams {
+		team := &entity.Team{
+			ID:    uuid.NewString(),
+			Name:  gofakeit.Vegetable(),
+			Badge: emoji.Random(),
+		}
+		r.Teams = append(r.Teams, team)
 
-	go func() {
-		state := statemachine.Stater(statemachine.Lobby{})
+		for _, p := range players {
+			r.Reservations[p.Id] = entity.Reservation{TeamID: team.ID, At: r.CreatedAt}
+		}
+	}
+
+	// matched rooms are not limited
+	_ = g.startRoom(r, "")
+	return r.Id
+}
 
+// startRoom checks limits of rooms if creatorID is not empty.
+func (g *Game) startRoom(r *entity.Room, creatorID string) error {
+	roomID := r.Id
+	rm := &room{
+		Room:      r,
+		state:     statemachine.NewLobby(g.env),
+		creatorID: creatorID,
+	}
+
+	g.roomsMu.Lock()
+	if creatorID != "" {
+		err := g.checkLimits(creatorID)
+		if err != nil {
+			g.roomsMu.Unlock()
+			return err
+		}
+	}
+	g.rooms[roomID] = rm
+	g.roomsMu.Unlock()
+
+	metrics.RoomsByState.WithLabelValues(statemachine.Name(rm.state)).Inc()
+
+	go func() {
 		for {
 			select {
 			case <-r.Ctx().Done():
 				return
 			case tuple := <-r.AggregationChan():
 				r.Do(func(r *entity.Room) {
-					var err error
-					state, err = state.HandleMessage(tuple.A, tuple.B, r)
+					stateName := statemachine.Name(rm.state)
+					msgType := entity.MessageType(tuple.B)
+					if !g.allowMessage(tuple.C, msgType) {
+						metrics.MessagesRateLimited.WithLabelValues(msgType).Inc()
+						tuple.C.Log().Warn().Str("message_type", msgType).Msg("player is rate limited")
+						_ = tuple.C.SendError(fmt.Sprintf("too many %s messages", msgType))
+						return
+					}
+					metrics.MessagesHandled.WithLabelValues(msgType).Inc()
+
+					ctx, span := tracing.Tracer().Start(tuple.A, "room.handle_message", trace.WithAttributes(
+						attribute.String("room.id", roomID),
+						attribute.String("room.state", stateName),
+						attribute.String("message.type", msgType),
+					))
+					r.SetTraceContext(ctx)
+					r.LastActive = time.Now()
+					defer func() {
+						r.SetTraceContext(nil)
+						span.End()
+					}()
+
+					// every state answers the same way
+					if tuple.B.GetRequestSnapshot() != nil {
+						_ = tuple.C.SendMsg(statemachine.Snapshot(rm.state, r))
+						return
+					}
+
+					next, err := rm.state.HandleMessage(tuple.B, tuple.C, r)
 					if err != nil {
-						_ = tuple.B.SendError(err.Error())
+						var unknownErr *statemachine.UnknownMessageTypeError
+						if errors.As(err, &unknownErr) {
+							metrics.UnknownMessages.WithLabelValues(stateName, msgType).Inc()
+						}
+
+						span.RecordError(err)
+						span.SetStatus(otelcodes.Error, err.Error())
+						_ = tuple.C.SendError(err.Error())
 					}
+					rm.setState(next)
 				})
 			}
 		}
 	}()
+	if g.idleTimeout > 0 {
+		go g.kickIdle(rm)
+	}
 	go func() {
 		r.Start()
 
 		g.roomsMu.Lock()
 		delete(g.rooms, roomID)
 		g.roomsMu.Unlock()
+
+		metrics.RoomsActive.Dec()
+		metrics.RoomsByState.WithLabelValues(statemachine.Name(rm.state)).Dec()
 	}()
 
-	g.roomsMu.Lock()
-	g.rooms[roomID] = r
-	g.roomsMu.Unlock()
+	metrics.RoomsActive.Inc()
+	return nil
+}
+
+// allowMessage reports whether the player may send a message of the type
+// now. It must be called by the room of the player.
+func (g *Game) allowMessage(p *entity.Player, msgType string) bool {
+	limit, ok := g.msgLimits.Types[msgType]
+	if !ok {
+		limit = g.msgLimits.Default
+	}
+	if limit.Rate == rate.Inf {
+		return true
+	}
+
+	if p.MessageLimiters == nil {
+		p.MessageLimiters = make(map[string]*rate.Limiter)
+	}
+	l, ok := p.MessageLimiters[msgType]
+	if !ok {
+		l = limit.Limiter()
+		p.MessageLimiters[msgType] = l
+	}
+	return l.Allow()
+}
+
+// checkLimits must be called with roomsMu held.
+func (g *Game) checkLimits(creatorID string) error {
+	if g.roomOpts.Max > 0 && len(g.rooms) >= g.roomOpts.Max {
+		return ErrTooManyRooms
+	}
+
+	if g.roomOpts.MaxPerPlayer > 0 {
+		var created int
+		for _, r := range g.rooms {
+			if r.creatorID == creatorID {
+				created += 1
+			}
+		}
+		if created >= g.roomOpts.MaxPerPlayer {
+			return ErrTooManyPlayerRooms
+		}
+	}
 
-	return roomID
+	return nil
 }
 
-func (g *Game) ListRooms() []*gamesvc.Room {
+// StartJanitor closes rooms that outlived their TTLs until ctx is done.
+func (g *Game) StartJanitor(ctx context.Context) {
+	if g.roomOpts.JanitorInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(g.roomOpts.JanitorInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			g.reapRooms(now)
+		}
+	}
+}
+
+// roomList returns the rooms open now. Rooms are queried without roomsMu, so
+// one busy room doesn't hold up creating and closing others.
+func (g *Game) roomList() []*room {
 	g.roomsMu.Lock()
 	defer g.roomsMu.Unlock()
 
-	roomsProto := make([]*gamesvc.Room, 0, len(g.rooms))
+	rooms := make([]*room, 0, len(g.rooms))
 	for _, r := range g.rooms {
-		proto := runFn1(r, func(r *entity.Room) *gamesvc.Room {
+		rooms = append(rooms, r)
+	}
+	return rooms
+}
+
+func (g *Game) reapRooms(now time.Time) {
+	for _, rm := range g.roomList() {
+		rm := rm
+		rm.Do(func(r *entity.Room) {
+			// slots of players who left are kept until the game ends
+			_, inLobby := rm.state.(statemachine.Lobby)
+			if inLobby && g.roomOpts.ReservationTTL > 0 {
+				r.ExpireReservations(now.Add(-g.roomOpts.ReservationTTL))
+			}
+
+			reason := reapReason(r, now, g.roomOpts)
+			if reason == "" {
+				return
+			}
+
+			g.log.Info().Str("room-id", r.Id).Str("reason", reason).Msg("closing room")
+			metrics.RoomsReaped.WithLabelValues(reason).Inc()
+
+			sendNotice("The room was closed for inactivity", r.GetAllPlayers()...)
+			// players leave when the room is done, the room is deleted
+			// once it stops
+			r.Cancel()
+		})
+	}
+}
+
+// reapReason returns why the room should be closed, or an empty string if
+// it should be kept.
+func reapReason(r *entity.Room, now time.Time, opts RoomOptions) string {
+	switch {
+	case r.LastActive.IsZero():
+		if opts.UnjoinedTTL > 0 && now.Sub(r.CreatedAt) >= opts.UnjoinedTTL {
+			return "unjoined"
+		}
+	case opts.IdleTTL > 0 && now.Sub(r.LastActive) >= opts.IdleTTL:
+		return "idle"
+	}
+	return ""
+}
+
+// kickIdle kicks players who are idle in the lobby of the room until the
+// room ends. Spectators of a game are not kicked, they may be players who
+// have reconnected and wait for the next game.
+func (g *Game) kickIdle(rm *room) {
+	ticker := time.NewTicker(g.idleTimeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-rm.Ctx().Done():
+			return
+		case <-ticker.C:
+			rm.Do(func(r *entity.Room) {
+				if _, ok := rm.state.(statemachine.Lobby); !ok {
+					return
+				}
+
+				for _, p := range r.Lobby {
+					if time.Since(p.LastSeen()) < g.idleTimeout {
+						continue
+					}
+
+					p.Log().Info().Time("last_seen", p.LastSeen()).Msg("kicking idle player")
+					metrics.IdleKicks.Inc()
+					sendNotice("You were disconnected for inactivity", p)
+					// the player is removed from the room when their
+					// connection ends
+					p.Disconnect()
+				}
+			})
+		}
+	}
+}
+
+// RoomQuery selects rooms to list. Empty fields match any room.
+type RoomQuery struct {
+	Language string
+	Statuses []gamesvc.RoomStatus
+	Offset   int
+	// Limit is the max number of rooms, zero is unlimited.
+	Limit int
+}
+
+func (q RoomQuery) match(r *gamesvc.Room) bool {
+	if q.Language != "" && r.Langugage != q.Language {
+		return false
+	}
+
+	if len(q.Statuses) == 0 {
+		return true
+	}
+	for _, status := range q.Statuses {
+		if r.Status == status {
+			return true
+		}
+	}
+	return false
+}
+
+// ListRooms returns rooms matching the query from the oldest to the newest.
+func (g *Game) ListRooms(q RoomQuery) []*gamesvc.Room {
+	type listed struct {
+		createdAt time.Time
+		proto     *gamesvc.Room
+	}
+
+	all := g.roomList()
+	rooms := make([]listed, 0, len(all))
+	for _, r := range all {
+		proto := runFn1(r.Room, func(r *entity.Room) *gamesvc.Room {
 			return r.GetProto()
 		})
 		// returns nil if room was deleted from map
-		if proto == nil {
+		if proto == nil || !q.match(proto) {
 			continue
 		}
 
-		roomsProto = append(roomsProto, proto)
+		rooms = append(rooms, listed{createdAt: r.CreatedAt, proto: proto})
+	}
+
+	// pages must not change between calls
+	sort.Slice(rooms, func(i, j int) bool {
+		if !rooms[i].createdAt.Equal(rooms[j].createdAt) {
+			return rooms[i].createdAt.Before(rooms[j].createdAt)
+		}
+		return rooms[i].proto.Id < rooms[j].proto.Id
+	})
+
+	if q.Offset >= len(rooms) {
+		return []*gamesvc.Room{}
+	}
+	rooms = rooms[q.Offset:]
+	if q.Limit > 0 && q.Limit < len(rooms) {
+		rooms = rooms[:q.Limit]
+	}
+
+	roomsProto := make([]*gamesvc.Room, len(rooms))
+	for i, r := range rooms {
+		roomsProto[i] = r.proto
 	}
 
 	return roomsProto
 }
 
+// FindPlayers returns IDs of rooms where the players are connected. Players
+// who are not in any room are absent from the result.
+func (g *Game) FindPlayers(playerIDs []string) map[string]string {
+	playerRooms := make(map[string]string)
+	for _, r := range g.roomList() {
+		found := runFn1(r.Room, func(r *entity.Room) []string {
+			var found []string
+			for _, id := range playerIDs {
+				if r.HasPlayer(id) {
+					found = append(found, id)
+				}
+			}
+			return found
+		})
+
+		for _, id := range found {
+			playerRooms[id] = r.Id
+		}
+	}
+
+	return playerRooms
+}
+
+// RoomInvite returns the room the player is connected to so that they can
+// invite others to it.
+func (g *Game) RoomInvite(roomID, playerID string) (*gamesvc.Room, *string, error) {
+	g.roomsMu.Lock()
+	r, ok := g.rooms[roomID]
+	g.roomsMu.Unlock()
+	if !ok {
+		return nil, nil, ErrRoomNotFound
+	}
+
+	type invite struct {
+		room     *gamesvc.Room
+		password *string
+		err      error
+	}
+	inv := runFn1(r.Room, func(r *entity.Room) invite {
+		if !r.HasPlayer(playerID) {
+			return invite{err: ErrPlayerNotInRoom}
+		}
+		return invite{room: r.GetProto(), password: r.Password}
+	})
+	if inv.room == nil && inv.err == nil {
+		// room was deleted while we were waiting
+		return nil, nil, ErrRoomNotFound
+	}
+
+	return inv.room, inv.password, inv.err
+}
+
+// ReserveTeam keeps slots in one team of the room for the players, so that
+// a party plays together. It returns password of the room, or
+// ErrNoTeamSpace if no team has enough space.
+func (g *Game) ReserveTeam(roomID string, playerIDs []string) (*string, error) {
+	g.roomsMu.Lock()
+	r, ok := g.rooms[roomID]
+	g.roomsMu.Unlock()
+	if !ok {
+		return nil, ErrRoomNotFound
+	}
+
+	type reserved struct {
+		password *string
+		found    bool
+		ok       bool
+	}
+	res := runFn1(r.Room, func(r *entity.Room) reserved {
+		ok := r.ReserveTeam(playerIDs)
+		return reserved{password: r.Password, found: true, ok: ok}
+	})
+	switch {
+	case !res.found:
+		// room was deleted while we were waiting
+		return nil, ErrRoomNotFound
+	case !res.ok:
+		return nil, ErrNoTeamSpace
+	}
+
+	return res.password, nil
+}
+
 func (g *Game) StartPlayerInRoom(
 	roomID string,
 	playerProto *gamesvc.Player,
-	socket gamesvc.GameService_JoinServer,
+	transport entity.Transport,
 ) error {
 	g.roomsMu.Lock()
 	r, ok := g.rooms[roomID]
@@ -109,21 +613,41 @@ func (g *Game) StartPlayerInRoom(
 		return ErrRoomNotFound
 	}
 
-	player := entity.NewPlayer(g.log, socket, playerProto, r)
+	player := entity.NewPlayer(g.log, transport, playerProto, r.Room, g.playerOpts)
 
-	err := runFn1(r, func(r *entity.Room) error {
+	type joined struct {
+		err error
+		ok  bool
+	}
+	res := runFn1(r.Room, func(_ *entity.Room) joined {
 		if r.HasPlayer(player.ID) {
-			return ErrPlayerInRoom
+			return joined{err: ErrPlayerInRoom, ok: true}
 		}
 
-		r.Lobby = append(r.Lobby, player)
+		if !r.PlaceReserved(player) {
+			r.Lobby = append(r.Lobby, player)
+		}
+		r.LastActive = time.Now()
 		r.AnnounceChange()
-		return nil
+
+		// a player who joins or reconnects during the game needs to know
+		// how it goes, the lobby is described by the update
+		if _, ok := r.state.(statemachine.Lobby); !ok {
+			_ = player.SendMsg(statemachine.Snapshot(r.state, r.Room))
+		}
+		return joined{ok: true}
 	})
-	if err != nil {
-		return err
+	switch {
+	case !res.ok:
+		// room was deleted while we were waiting
+		return ErrRoomNotFound
+	case res.err != nil:
+		return res.err
 	}
 
+	metrics.PlayersConnected.Inc()
+	defer metrics.PlayersConnected.Dec()
+
 	ctx, cancel := context.WithCancel(r.Ctx())
 
 	go func() {
@@ -138,7 +662,7 @@ func (g *Game) StartPlayerInRoom(
 				}
 
 				select {
-				case r.AggregationChan() <- tuple.NewT2(msg, player):
+				case r.AggregationChan() <- tuple.NewT3(msg.A, msg.B, player):
 					continue
 				case <-ctx.Done():
 					return
@@ -147,31 +671,50 @@ func (g *Game) StartPlayerInRoom(
 		}
 	}()
 
-	err = player.Start(ctx)
+	err := player.Start(ctx)
 	cancel()
-	if err != nil {
+	switch {
+	case errors.Is(err, entity.ErrSlowConsumer):
+		// the client can tell it from other errors and join again
+		err = status.Error(codes.ResourceExhausted, err.Error())
+	case err != nil:
 		if status.Code(err) != codes.Canceled {
-			g.log.
+			player.Log().
 				Err(err).
 				Stringer("status_code", status.Code(err)).
-				Interface("player", player).
 				Msg("tried to send message and something went wrong")
 		}
 
 		err = fmt.Errorf("player loop: %w", err)
 	}
 
-	r.Do(func(r *entity.Room) {
-		needsAnnounce := r.RemovePlayer(player.ID)
+	r.Do(func(_ *entity.Room) {
+		var needsAnnounce bool
+		if _, ok := r.state.(statemachine.Lobby); ok {
+			needsAnnounce = r.RemovePlayer(player.ID)
+		} else {
+			// teams can't change during the game, the player gets back to
+			// their team if they reconnect
+			needsAnnounce = r.KeepSlot(player.ID) || r.RemovePlayer(player.ID)
+		}
+		r.LastActive = time.Now()
 
 		if r.IsEmpty() {
 			r.Cancel()
 			return
 		}
 
+		// the game needs a leader to be resumed or ended
+		if r.LeaderId == player.ID && r.PassLeadership() {
+			needsAnnounce = true
+		}
+
 		if needsAnnounce {
 			r.AnnounceChange()
 		}
+
+		// nobody can explain the word until the explainer is back
+		r.setState(statemachine.PauseOnLeave(r.state, player.ID, r.Room))
 	})
 
 	return err
@@ -186,9 +729,16 @@ func runFn1[R1 any](r *entity.Room, fn func(r *entity.Room) R1) R1 {
 		r1 = fn(r)
 	})
 	select {
-	case <-r.Ctx().Done():
 	case <-wait:
+		return r1
+	case <-r.Ctx().Done():
+		// fn may be still running if it has cancelled the room
+		select {
+		case <-wait:
+			return r1
+		default:
+			var zero R1
+			return zero
+		}
 	}
-
-	return r1
 }
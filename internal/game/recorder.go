@@ -0,0 +1,47 @@
+package game
+
+import (
+	"context"
+	"time"
+
+	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	"github.com/knightpp/alias-server/internal/game/statemachine"
+	"github.com/knightpp/alias-server/internal/storage"
+	"github.com/rs/zerolog"
+)
+
+const saveMatchTimeout = 5 * time.Second
+
+var _ statemachine.MatchRecorder = matchRecorder{}
+
+type matchRecorder struct {
+	log zerolog.Logger
+	db  storage.Storage
+}
+
+func newMatchRecorder(log zerolog.Logger, db storage.Storage) matchRecorder {
+	return matchRecorder{
+		log: log,
+		db:  db,
+	}
+}
+
+// RecordMatch saves the match and updates leaderboards in background to not
+// block the room.
+func (mr matchRecorder) RecordMatch(match *gamesvc.Match) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), saveMatchTimeout)
+		defer cancel()
+
+		err := mr.db.SaveMatch(ctx, match)
+		if err != nil {
+			mr.log.Err(err).Str("match-id", match.Id).Msg("could not save match")
+			return
+		}
+
+		err = mr.db.UpdateLeaderboards(ctx, match)
+		if err != nil {
+			mr.log.Err(err).Str("match-id", match.Id).Msg("could not update leaderboards")
+		}
+	}()
+}
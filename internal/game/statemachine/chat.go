@@ -0,0 +1,104 @@
+package statemachine
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	"github.com/knightpp/alias-server/internal/game/entity"
+	"golang.org/x/time/rate"
+)
+
+var (
+	ErrChatEmpty       = errors.New("chat message is empty")
+	ErrChatRateLimited = errors.New("too many chat messages")
+)
+
+// ChatFilter checks chat messages before they are sent to the room. It may
+// return changed text, e.g. with masked words, or an error to reject the
+// message.
+type ChatFilter interface {
+	FilterChat(sender *entity.Player, text string) (string, error)
+}
+
+type ChatFilterFunc func(sender *entity.Player, text string) (string, error)
+
+func (f ChatFilterFunc) FilterChat(sender *entity.Player, text string) (string, error) {
+	return f(sender, text)
+}
+
+type ChatOptions struct {
+	// MaxLength is the largest message in characters.
+	MaxLength int
+	// Rate and Burst limit how often a player can send messages.
+	Rate  rate.Limit
+	Burst int
+	// Filter is optional.
+	Filter ChatFilter
+}
+
+func DefaultChatOptions() ChatOptions {
+	return ChatOptions{
+		MaxLength: 300,
+		Rate:      rate.Every(time.Second),
+		Burst:     5,
+	}
+}
+
+// handleChat broadcasts a chat message to the room. It's allowed in every
+// state, check is an extra rule of the state and may be nil.
+func handleChat(
+	env *Env,
+	msg *gamesvc.MsgChat,
+	sender *entity.Player,
+	r *entity.Room,
+	check func(text string) error,
+) error {
+	opts := DefaultChatOptions()
+	if env != nil {
+		opts = env.Chat
+	}
+
+	text := strings.TrimSpace(msg.GetText())
+	if text == "" {
+		return ErrChatEmpty
+	}
+	if utf8.RuneCountInString(text) > opts.MaxLength {
+		return fmt.Errorf("chat message is longer than %d characters", opts.MaxLength)
+	}
+
+	if sender.ChatLimiter == nil {
+		sender.ChatLimiter = rate.NewLimiter(opts.Rate, opts.Burst)
+	}
+	if !sender.ChatLimiter.Allow() {
+		return ErrChatRateLimited
+	}
+
+	if check != nil {
+		err := check(text)
+		if err != nil {
+			return err
+		}
+	}
+
+	if opts.Filter != nil {
+		var err error
+		text, err = opts.Filter.FilterChat(sender, text)
+		if err != nil {
+			return err
+		}
+	}
+
+	sendMsgToPlayers(&gamesvc.Message{
+		Message: &gamesvc.Message_Chat{
+			Chat: &gamesvc.MsgChat{
+				PlayerId: sender.ID,
+				Text:     text,
+			},
+		},
+	}, r.GetAllPlayers()...)
+	return nil
+}
@@ -0,0 +1,85 @@
+package statemachine
+
+import (
+	"time"
+
+	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	"github.com/knightpp/alias-server/internal/game/entity"
+)
+
+// Description is an internal state of a room shown to operators.
+type Description struct {
+	// State is a name of the state, e.g. "Lobby".
+	State        string
+	PlayerIDTurn string
+	// TurnDeadline is zero outside of a turn.
+	TurnDeadline time.Time
+	// TurnRemaining is the time left of a paused turn.
+	TurnRemaining time.Duration
+	// Stats are copied, so the description can leave the room.
+	Stats map[string]*gamesvc.Statistics
+}
+
+// Name returns a name of the state, e.g. "Lobby".
+func Name(state Stater) string {
+	switch state.(type) {
+	case Lobby:
+		return "Lobby"
+	case Game:
+		return "Game"
+	case Turn:
+		return "Turn"
+	case Paused:
+		return "Paused"
+	case nil:
+		return "None"
+	default:
+		return "Unknown"
+	}
+}
+
+// Status returns a phase of the game shown to players browsing rooms.
+func Status(state Stater) gamesvc.RoomStatus {
+	switch state.(type) {
+	case Game:
+		return gamesvc.RoomStatus_ROOM_STATUS_IN_GAME
+	case Turn:
+		return gamesvc.RoomStatus_ROOM_STATUS_TURN
+	case Paused:
+		return gamesvc.RoomStatus_ROOM_STATUS_PAUSED
+	default:
+		return gamesvc.RoomStatus_ROOM_STATUS_LOBBY
+	}
+}
+
+// Describe describes the state, which may be nil.
+func Describe(state Stater) Description {
+	if state == nil {
+		return Description{State: Name(state)}
+	}
+	return state.Describe()
+}
+
+// Snapshot returns the full state of the room for a player who joins late
+// or asks for it.
+func Snapshot(state Stater, r *entity.Room) *gamesvc.Message {
+	d := Describe(state)
+
+	var deadline int64
+	if !d.TurnDeadline.IsZero() {
+		deadline = d.TurnDeadline.UnixMilli()
+	}
+
+	return &gamesvc.Message{
+		Message: &gamesvc.Message_Snapshot{
+			Snapshot: &gamesvc.MsgSnapshot{
+				Room:                  r.GetProto(),
+				State:                 d.State,
+				PlayerIdTurn:          d.PlayerIDTurn,
+				TurnDeadlineUnixMs:    deadline,
+				TeamIdToStats:         d.Stats,
+				PausedTurnRemainingMs: uint64(d.TurnRemaining.Milliseconds()),
+			},
+		},
+	}
+}
@@ -7,13 +7,30 @@ import (
 
 	gamesvc "github.com/knightpp/alias-proto/go/game_service"
 	"github.com/knightpp/alias-server/internal/game/entity"
+	"google.golang.org/protobuf/proto"
 )
 
 var _ Stater = Game{}
 
 type Game struct {
+	env          *Env
 	stats        map[string]*gamesvc.Statistics
 	playerIDTurn string
+	// match is filled during the game and recorded when it ends.
+	match *gamesvc.Match
+}
+
+func (g Game) Describe() Description {
+	stats := make(map[string]*gamesvc.Statistics, len(g.stats))
+	for teamID, s := range g.stats {
+		stats[teamID] = proto.Clone(s).(*gamesvc.Statistics)
+	}
+
+	return Description{
+		State:        Name(g),
+		PlayerIDTurn: g.playerIDTurn,
+		Stats:        stats,
+	}
 }
 
 func (g Game) HandleMessage(message *gamesvc.Message, p *entity.Player, r *entity.Room) (Stater, error) {
@@ -22,6 +39,10 @@ func (g Game) HandleMessage(message *gamesvc.Message, p *entity.Player, r *entit
 		return g.handleStartTurn(msg.StartTurn, p, r)
 	case *gamesvc.Message_EndGame:
 		return g.handleEndGame(msg.EndGame, p, r)
+	case *gamesvc.Message_Pause:
+		return g.handlePause(msg.Pause, p, r)
+	case *gamesvc.Message_Chat:
+		return g, handleChat(g.env, msg.Chat, p, r, nil)
 	default:
 		return g, &UnknownMessageTypeError{T: message.Message}
 	}
@@ -35,16 +56,13 @@ func (g Game) handleStartTurn(msg *gamesvc.MsgStartTurn, p *entity.Player, r *en
 		return g, errors.New("could not start turn with 0 duration")
 	}
 
-	err := sendMsgToPlayers(&gamesvc.Message{
+	sendMsgToPlayers(&gamesvc.Message{
 		Message: &gamesvc.Message_StartTurn{
 			StartTurn: &gamesvc.MsgStartTurn{
 				DurationMs: msg.GetDurationMs(),
 			},
 		},
 	}, r.GetAllPlayers()...)
-	if err != nil {
-		return nil, err
-	}
 
 	deadline := time.Now().Add(time.Duration(msg.DurationMs) * time.Millisecond)
 	return newTurn(deadline, g), nil
@@ -55,7 +73,7 @@ func (g Game) handleEndGame(_ *gamesvc.MsgEndGame, sender *entity.Player, r *ent
 		return g, errors.New("only leader can end game")
 	}
 
-	err := sendMsgToPlayers(&gamesvc.Message{
+	sendMsgToPlayers(&gamesvc.Message{
 		Message: &gamesvc.Message_Results{
 			Results: &gamesvc.MsgResults{
 				TeamIdToStats: g.stats,
@@ -63,5 +81,60 @@ func (g Game) handleEndGame(_ *gamesvc.MsgEndGame, sender *entity.Player, r *ent
 		},
 	}, r.GetAllPlayers()...)
 
-	return Lobby{}, err
+	g.recordMatch()
+
+	return Lobby{
+		env:           g.env,
+		results:       true,
+		prevMatch:     g.match,
+		prevFirstTurn: g.playerIDTurn,
+	}, nil
+}
+
+func (g Game) handlePause(_ *gamesvc.MsgPause, sender *entity.Player, r *entity.Room) (Stater, error) {
+	if r.LeaderId != sender.ID {
+		return g, errors.New("only leader can pause game")
+	}
+
+	return pause(g, 0, sender.ID, r), nil
+}
+
+func (g Game) recordMatch() {
+	if g.env == nil || g.env.Recorder == nil || len(g.match.Turns) == 0 {
+		return
+	}
+
+	var (
+		bestScore int64
+		winners   int
+	)
+	for _, team := range g.match.Teams {
+		stats, ok := g.stats[team.Id]
+		if !ok {
+			continue
+		}
+
+		team.Stats = &gamesvc.Statistics{
+			Rights:    stats.GetRights(),
+			Wrongs:    stats.GetWrongs(),
+			Penalties: stats.GetPenalties(),
+		}
+
+		score := int64(team.Stats.Rights) - int64(team.Stats.Penalties)
+		switch {
+		case winners == 0 || score > bestScore:
+			bestScore = score
+			g.match.WinnerTeamId = team.Id
+			winners = 1
+		case score == bestScore:
+			winners += 1
+		}
+	}
+	// nobody wins a draw
+	if winners > 1 {
+		g.match.WinnerTeamId = ""
+	}
+
+	g.match.EndedAtMs = time.Now().UnixMilli()
+	g.env.Recorder.RecordMatch(g.match)
 }
@@ -3,17 +3,41 @@ package statemachine
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/brianvoe/gofakeit/v6"
 	"github.com/google/uuid"
 	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	"github.com/knightpp/alias-server/internal/emoji"
 	"github.com/knightpp/alias-server/internal/game/entity"
+	"github.com/knightpp/alias-server/internal/uuidgen"
 	"github.com/life4/genesis/slices"
 )
 
 var _ Stater = Game{}
 
-type Lobby struct{}
+type Lobby struct {
+	env *Env
+	// results of the last game are shown, they are hidden once teams or
+	// the rematch vote change.
+	results bool
+	// prevMatch is the match of the last game, it's nil before the first
+	// game.
+	prevMatch *gamesvc.Match
+	// prevFirstTurn is ID of the player who explained first in the last
+	// game.
+	prevFirstTurn string
+	// rematch is the proposed rematch, it's nil if nobody proposed it.
+	rematch *rematchVote
+}
+
+func NewLobby(env *Env) Lobby {
+	return Lobby{env: env}
+}
+
+func (l Lobby) Describe() Description {
+	return Description{State: Name(l)}
+}
 
 func (l Lobby) HandleMessage(message *gamesvc.Message, p *entity.Player, r *entity.Room) (Stater, error) {
 	switch msg := message.Message.(type) {
@@ -25,6 +49,17 @@ func (l Lobby) HandleMessage(message *gamesvc.Message, p *entity.Player, r *enti
 		return l.handleTransferLeadership(msg, p, r)
 	case *gamesvc.Message_StartGame:
 		return l.handleStartGame(msg.StartGame, p, r)
+	case *gamesvc.Message_ProposeRematch:
+		return l.handleProposeRematch(msg.ProposeRematch, p, r)
+	case *gamesvc.Message_RematchVote:
+		return l.handleRematchVote(msg.RematchVote, p, r)
+	case *gamesvc.Message_Chat:
+		return l, handleChat(l.env, msg.Chat, p, r, nil)
+	case *gamesvc.Message_Reaction:
+		if !l.results {
+			return l, ErrReactionOutsideTurns
+		}
+		return l, handleReaction(l.env, msg.Reaction, p, r)
 	default:
 		return l, &UnknownMessageTypeError{T: message.Message}
 	}
@@ -39,8 +74,10 @@ func (l Lobby) handleCreateTeam(msg *gamesvc.Message_CreateTeam, p *entity.Playe
 	}
 	if team.Name == "" {
 		team.Name = gofakeit.Vegetable()
+		team.Badge = emoji.Random()
 	}
 	r.Teams = append(r.Teams, team)
+	l.results = false
 
 	resp := &gamesvc.Message{
 		Message: &gamesvc.Message_TeamCreated{
@@ -49,10 +86,7 @@ func (l Lobby) handleCreateTeam(msg *gamesvc.Message_CreateTeam, p *entity.Playe
 			},
 		},
 	}
-	err := sendMsgToPlayers(resp, r.GetAllPlayers()...)
-	if err != nil {
-		return l, err
-	}
+	sendMsgToPlayers(resp, r.GetAllPlayers()...)
 
 	return l, nil
 }
@@ -65,15 +99,17 @@ func (l Lobby) handleJoinTeam(msg *gamesvc.Message_JoinTeam, p *entity.Player, r
 		return l, fmt.Errorf("TODO: team not found")
 	}
 
+	if !r.TeamHasSpace(team, p.ID) {
+		return l, fmt.Errorf("TODO: team is full")
+	}
+
 	r.RemovePlayer(p.ID)
-	switch {
-	case team.PlayerA == nil:
+	if team.PlayerA == nil {
 		team.PlayerA = p
-	case team.PlayerB == nil:
+	} else {
 		team.PlayerB = p
-	default:
-		return l, fmt.Errorf("TODO: team is full")
 	}
+	l.results = false
 
 	r.AnnounceChange()
 	return l, nil
@@ -97,8 +133,9 @@ func (l Lobby) handleStartGame(msg *gamesvc.MsgStartGame, sender *entity.Player,
 		return l, errors.New("only leader id can start game")
 	}
 
-	if len(r.Teams) == 0 {
-		return l, entity.ErrStartNoTeams
+	err := checkTeams(r)
+	if err != nil {
+		return l, err
 	}
 
 	nextTurn := msg.GetNextPlayerTurn()
@@ -106,32 +143,67 @@ func (l Lobby) handleStartGame(msg *gamesvc.MsgStartGame, sender *entity.Player,
 		return l, fmt.Errorf("next player turn should not be empty")
 	}
 
-	for _, team := range r.Teams {
-		if team.PlayerA == nil || team.PlayerB == nil {
-			return l, entity.ErrStartIncompleteTeam
-		}
-	}
-
 	ok := r.HasPlayer(nextTurn)
 	if !ok {
 		return l, fmt.Errorf("cannot start game: no player with %q id", nextTurn)
 	}
 
-	players := r.GetAllPlayers()
+	return l.startGame(nextTurn, "", r)
+}
+
+// checkTeams returns an error if the game can't be played by the teams.
+func checkTeams(r *entity.Room) error {
+	if len(r.Teams) == 0 {
+		return entity.ErrStartNoTeams
+	}
+
+	for _, team := range r.Teams {
+		if team.PlayerA == nil || team.PlayerB == nil {
+			return entity.ErrStartIncompleteTeam
+		}
+	}
+
+	return nil
+}
 
-	err := sendMsgToPlayers(&gamesvc.Message{
+// startGame tells players that the game starts. rematchOf is ID of the
+// match it's a rematch of, it's empty for new games.
+func (l Lobby) startGame(nextTurn, rematchOf string, r *entity.Room) (Stater, error) {
+	sendMsgToPlayers(&gamesvc.Message{
 		Message: &gamesvc.Message_StartGame{
 			StartGame: &gamesvc.MsgStartGame{
 				NextPlayerTurn: nextTurn,
 			},
 		},
-	}, players...)
-	if err != nil {
-		return l, err
-	}
+	}, r.GetAllPlayers()...)
+
+	match := newMatch(r)
+	match.RematchOf = rematchOf
 
 	return Game{
+		env:          l.env,
 		stats:        make(map[string]*gamesvc.Statistics),
 		playerIDTurn: nextTurn,
+		match:        match,
 	}, nil
 }
+
+func newMatch(r *entity.Room) *gamesvc.Match {
+	teams := make([]*gamesvc.MatchTeam, len(r.Teams))
+	for i, t := range r.Teams {
+		teams[i] = &gamesvc.MatchTeam{
+			Id:      t.ID,
+			Name:    t.Name,
+			Players: []*gamesvc.Player{t.PlayerA.ToProto(), t.PlayerB.ToProto()},
+		}
+	}
+
+	return &gamesvc.Match{
+		Id:          uuidgen.NewString(),
+		RoomId:      r.Id,
+		RoomName:    r.Name,
+		Langugage:   r.Langugage,
+		StartedAtMs: time.Now().UnixMilli(),
+		Teams:       teams,
+	}
+}
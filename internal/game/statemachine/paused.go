@@ -0,0 +1,125 @@
+package statemachine
+
+import (
+	"errors"
+	"time"
+
+	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	"github.com/knightpp/alias-server/internal/game/entity"
+)
+
+var _ Stater = Paused{}
+
+var ErrPaused = errors.New("game is paused")
+
+// Paused freezes a game or a turn until the leader resumes it.
+type Paused struct {
+	// prev is Game or Turn.
+	prev Stater
+	// remaining is the time left of a paused turn.
+	remaining time.Duration
+	// pausedBy is ID of the player who paused the game, or of the explainer
+	// who has left.
+	pausedBy string
+}
+
+func (p Paused) Describe() Description {
+	d := p.prev.Describe()
+	d.State = Name(p)
+	d.TurnDeadline = time.Time{}
+	d.TurnRemaining = p.remaining
+	return d
+}
+
+func (p Paused) HandleMessage(message *gamesvc.Message, sender *entity.Player, r *entity.Room) (Stater, error) {
+	switch msg := message.Message.(type) {
+	case *gamesvc.Message_Resume:
+		return p.handleResume(msg.Resume, sender, r)
+	case *gamesvc.Message_Pause:
+		return p, errors.New("game is already paused")
+	case *gamesvc.Message_StartTurn, *gamesvc.Message_EndTurn, *gamesvc.Message_Word:
+		return p, ErrPaused
+	case *gamesvc.Message_EndGame:
+		return p.game().handleEndGame(msg.EndGame, sender, r)
+	case *gamesvc.Message_Chat:
+		// the explainer of a paused turn still can't give away the word
+		next, err := p.prev.HandleMessage(message, sender, r)
+		p.prev = next
+		return p, err
+	case *gamesvc.Message_Reaction:
+		if _, ok := p.prev.(Turn); !ok {
+			return p, ErrReactionOutsideTurns
+		}
+		return p, handleReaction(p.game().env, msg.Reaction, sender, r)
+	default:
+		return p, &UnknownMessageTypeError{T: message.Message}
+	}
+}
+
+// game returns the paused game, or the game of the paused turn.
+func (p Paused) game() Game {
+	if turn, ok := p.prev.(Turn); ok {
+		return turn.prev
+	}
+	return p.prev.(Game)
+}
+
+func (p Paused) handleResume(_ *gamesvc.MsgResume, sender *entity.Player, r *entity.Room) (Stater, error) {
+	if sender.ID != r.LeaderId && sender.ID != p.pausedBy {
+		return p, errors.New("only leader can resume game")
+	}
+
+	next := p.prev
+	if turn, ok := next.(Turn); ok {
+		turn.turnDeadline = time.Now().Add(p.remaining)
+		next = turn
+	}
+
+	sendMsgToPlayers(&gamesvc.Message{
+		Message: &gamesvc.Message_Resume{
+			Resume: &gamesvc.MsgResume{
+				PlayerId:        sender.ID,
+				RemainingTurnMs: uint64(p.remaining.Milliseconds()),
+			},
+		},
+	}, r.GetAllPlayers()...)
+
+	return next, nil
+}
+
+// pause freezes the game or the turn and tells everyone in the room.
+func pause(prev Stater, remaining time.Duration, playerID string, r *entity.Room) Stater {
+	p := Paused{
+		prev:      prev,
+		remaining: remaining,
+		pausedBy:  playerID,
+	}
+
+	sendMsgToPlayers(&gamesvc.Message{
+		Message: &gamesvc.Message_Pause{
+			Pause: &gamesvc.MsgPause{
+				PlayerId:        playerID,
+				RemainingTurnMs: uint64(remaining.Milliseconds()),
+			},
+		},
+	}, r.GetAllPlayers()...)
+
+	return p
+}
+
+// PauseOnLeave pauses the game if the player who explains now, or explains
+// next, has left the room. Other states are returned as is.
+func PauseOnLeave(state Stater, playerID string, r *entity.Room) Stater {
+	switch s := state.(type) {
+	case Turn:
+		if s.prev.playerIDTurn == playerID {
+			return pause(s, s.remaining(), playerID, r)
+		}
+	case Game:
+		if s.playerIDTurn == playerID {
+			return pause(s, 0, playerID, r)
+		}
+	}
+
+	return state
+}
@@ -0,0 +1,61 @@
+package statemachine
+
+import (
+	"errors"
+	"time"
+
+	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	"github.com/knightpp/alias-server/internal/emoji"
+	"github.com/knightpp/alias-server/internal/game/entity"
+	"golang.org/x/time/rate"
+)
+
+var (
+	ErrReactionNotAllowed   = errors.New("reaction is not an allowed emoji")
+	ErrReactionRateLimited  = errors.New("too many reactions")
+	ErrReactionOutsideTurns = errors.New("reactions are allowed during a turn and on results")
+)
+
+type ReactionOptions struct {
+	// Categories are allowed emoji, all categories are allowed if empty.
+	Categories []emoji.Emojier
+	// Rate and Burst limit how often a player can react.
+	Rate  rate.Limit
+	Burst int
+}
+
+func DefaultReactionOptions() ReactionOptions {
+	return ReactionOptions{
+		Rate:  rate.Every(500 * time.Millisecond),
+		Burst: 3,
+	}
+}
+
+// handleReaction broadcasts an emoji reaction to the room.
+func handleReaction(env *Env, msg *gamesvc.MsgReaction, sender *entity.Player, r *entity.Room) error {
+	opts := DefaultReactionOptions()
+	if env != nil {
+		opts = env.Reactions
+	}
+
+	if !emoji.IsOneOf(msg.GetEmoji(), opts.Categories...) {
+		return ErrReactionNotAllowed
+	}
+
+	if sender.ReactionLimiter == nil {
+		sender.ReactionLimiter = rate.NewLimiter(opts.Rate, opts.Burst)
+	}
+	if !sender.ReactionLimiter.Allow() {
+		return ErrReactionRateLimited
+	}
+
+	sendMsgToPlayers(&gamesvc.Message{
+		Message: &gamesvc.Message_Reaction{
+			Reaction: &gamesvc.MsgReaction{
+				PlayerId: sender.ID,
+				Emoji:    msg.GetEmoji(),
+			},
+		},
+	}, r.GetAllPlayers()...)
+	return nil
+}
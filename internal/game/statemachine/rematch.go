@@ -0,0 +1,166 @@
+package statemachine
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+
+	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	"github.com/knightpp/alias-server/internal/game/entity"
+)
+
+// rematchVote is a rematch proposed by the leader. Only players in teams
+// vote, the rematch starts when more than half of them accept it.
+type rematchVote struct {
+	proposedBy    string
+	swapTurnOrder bool
+	shuffleTeams  bool
+	// votes maps ID of a player to whether they accepted the rematch.
+	votes map[string]bool
+}
+
+func (l Lobby) handleProposeRematch(msg *gamesvc.MsgProposeRematch, sender *entity.Player, r *entity.Room) (Stater, error) {
+	if r.LeaderId != sender.ID {
+		return l, errors.New("only leader can propose rematch")
+	}
+	if l.prevMatch == nil {
+		return l, errors.New("there is no game to rematch")
+	}
+
+	err := checkTeams(r)
+	if err != nil {
+		return l, err
+	}
+
+	l.rematch = &rematchVote{
+		proposedBy:    sender.ID,
+		swapTurnOrder: msg.GetSwapTurnOrder(),
+		shuffleTeams:  msg.GetShuffleTeams(),
+		votes:         map[string]bool{sender.ID: true},
+	}
+
+	return l.countVotes(r)
+}
+
+func (l Lobby) handleRematchVote(msg *gamesvc.MsgRematchVote, sender *entity.Player, r *entity.Room) (Stater, error) {
+	if l.rematch == nil {
+		return l, errors.New("nobody proposed rematch")
+	}
+
+	_, ok := r.FindTeamWithPlayer(sender.ID)
+	if !ok {
+		return l, errors.New("only players in teams can vote for rematch")
+	}
+
+	l.rematch.votes[sender.ID] = msg.GetAccept()
+
+	return l.countVotes(r)
+}
+
+// countVotes tells players how the vote goes and starts the rematch once the
+// quorum accepts it. Votes of players who have left teams are not counted.
+func (l Lobby) countVotes(r *entity.Room) (Stater, error) {
+	var voters int
+	status := &gamesvc.MsgRematchStatus{
+		ProposedBy:    l.rematch.proposedBy,
+		SwapTurnOrder: l.rematch.swapTurnOrder,
+		ShuffleTeams:  l.rematch.shuffleTeams,
+	}
+	for _, team := range r.Teams {
+		for _, p := range []*entity.Player{team.PlayerA, team.PlayerB} {
+			if p == nil {
+				continue
+			}
+			voters += 1
+
+			accepted, voted := l.rematch.votes[p.ID]
+			switch {
+			case !voted:
+			case accepted:
+				status.AcceptedPlayerIds = append(status.AcceptedPlayerIds, p.ID)
+			default:
+				status.DeclinedPlayerIds = append(status.DeclinedPlayerIds, p.ID)
+			}
+		}
+	}
+	sort.Strings(status.AcceptedPlayerIds)
+	sort.Strings(status.DeclinedPlayerIds)
+
+	quorum := voters/2 + 1
+	status.Quorum = uint32(quorum)
+	status.Rejected = len(status.DeclinedPlayerIds) > voters-quorum
+
+	sendMsgToPlayers(&gamesvc.Message{
+		Message: &gamesvc.Message_RematchStatus{
+			RematchStatus: status,
+		},
+	}, r.GetAllPlayers()...)
+
+	switch {
+	case status.Rejected:
+		l.rematch = nil
+		l.results = false
+		return l, nil
+	case len(status.AcceptedPlayerIds) < quorum:
+		return l, nil
+	}
+
+	return l.startRematch(r)
+}
+
+// startRematch starts a game with the teams of the last one. Players explain
+// in the same order unless the turn order is swapped or teams are shuffled.
+func (l Lobby) startRematch(r *entity.Room) (Stater, error) {
+	vote := l.rematch
+	l.rematch = nil
+	l.results = false
+
+	err := checkTeams(r)
+	if err != nil {
+		return l, err
+	}
+
+	nextTurn := l.prevFirstTurn
+	switch {
+	case vote.shuffleTeams:
+		shuffleTeams(r)
+		r.AnnounceChange()
+		nextTurn = r.Teams[0].PlayerA.ID
+	case vote.swapTurnOrder:
+		team, ok := r.FindTeamWithPlayer(nextTurn)
+		if ok {
+			partner, _ := team.OponentOf(nextTurn)
+			nextTurn = partner.ID
+		}
+	}
+	// the first explainer may have left teams, players in the lobby can't
+	// explain
+	if _, ok := r.FindTeamWithPlayer(nextTurn); !ok {
+		nextTurn = r.Teams[0].PlayerA.ID
+	}
+
+	// a match without turns isn't recorded
+	var rematchOf string
+	if len(l.prevMatch.Turns) != 0 {
+		rematchOf = l.prevMatch.Id
+	}
+
+	return l.startGame(nextTurn, rematchOf, r)
+}
+
+// shuffleTeams moves players in teams to random slots. Teams must be
+// complete.
+func shuffleTeams(r *entity.Room) {
+	players := make([]*entity.Player, 0, 2*len(r.Teams))
+	for _, team := range r.Teams {
+		players = append(players, team.PlayerA, team.PlayerB)
+	}
+
+	rand.Shuffle(len(players), func(i, j int) {
+		players[i], players[j] = players[j], players[i]
+	})
+
+	for i, team := range r.Teams {
+		team.PlayerA, team.PlayerB = players[2*i], players[2*i+1]
+	}
+}
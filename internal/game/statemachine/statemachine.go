@@ -1,15 +1,51 @@
 package statemachine
 
 import (
-	"errors"
 	"fmt"
 
 	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	"github.com/knightpp/alias-server/internal/cheat"
 	"github.com/knightpp/alias-server/internal/game/entity"
+	"github.com/knightpp/alias-server/internal/metrics"
 )
 
 type Stater interface {
 	HandleMessage(message *gamesvc.Message, player *entity.Player, room *entity.Room) (Stater, error)
+	// Describe returns the state that players and operators may see.
+	Describe() Description
+}
+
+// MatchRecorder receives every finished match. It's called from the room
+// actor, so implementations must not block.
+type MatchRecorder interface {
+	RecordMatch(match *gamesvc.Match)
+}
+
+// Env holds dependencies shared by all states of a room.
+type Env struct {
+	Recorder  MatchRecorder
+	Chat      ChatOptions
+	Cheat     CheatOptions
+	Reactions ReactionOptions
+}
+
+// LeakDetector finds the word in text of the explainer.
+type LeakDetector interface {
+	Leaks(language, word, text string) bool
+}
+
+type CheatOptions struct {
+	Detector LeakDetector
+	// Penalty is added to penalties of the team every time the explainer
+	// gives away the word.
+	Penalty uint32
+}
+
+func DefaultCheatOptions() CheatOptions {
+	return CheatOptions{
+		Detector: cheat.New(),
+		Penalty:  1,
+	}
 }
 
 type UnknownMessageTypeError struct {
@@ -20,13 +56,15 @@ func (err *UnknownMessageTypeError) Error() string {
 	return fmt.Sprintf("unhandled message: %T", err.T)
 }
 
-func sendMsgToPlayers(msg *gamesvc.Message, players ...*entity.Player) error {
-	var errs []error
+// sendMsgToPlayers queues the message for every player. A player who can't
+// get it is disconnected or misses it, so their errors are only logged and
+// don't stop others from getting the message.
+func sendMsgToPlayers(msg *gamesvc.Message, players ...*entity.Player) {
 	for _, player := range players {
 		err := player.SendMsg(msg)
 		if err != nil {
-			errs = append(errs, err)
+			metrics.SendErrors.Inc()
+			player.Log().Warn().Err(err).Str("message_type", entity.MessageType(msg)).Msg("could not send message")
 		}
 	}
-	return errors.Join(errs...)
 }
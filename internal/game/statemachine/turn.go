@@ -12,9 +12,13 @@ import (
 
 var _ Stater = Turn{}
 
+var ErrWordLeaked = errors.New("explainer cannot give away the word, penalty applied")
+
 type Turn struct {
 	turnDeadline time.Time
 	prev         Game
+	// word is the last word the explainer sent.
+	word string
 }
 
 func newTurn(deadline time.Time, prev Game) Turn {
@@ -24,12 +28,28 @@ func newTurn(deadline time.Time, prev Game) Turn {
 	}
 }
 
+// Describe doesn't show the word, only the explainer knows it.
+func (t Turn) Describe() Description {
+	d := t.prev.Describe()
+	d.State = Name(t)
+	d.TurnDeadline = t.turnDeadline
+	return d
+}
+
 func (t Turn) HandleMessage(message *gamesvc.Message, sender *entity.Player, r *entity.Room) (Stater, error) {
 	switch msg := message.Message.(type) {
 	case *gamesvc.Message_EndTurn:
 		return t.handleEndTurn(msg.EndTurn, sender, r)
 	case *gamesvc.Message_Word:
 		return t.handleWord(msg.Word, sender, r)
+	case *gamesvc.Message_Pause:
+		return t.handlePause(msg.Pause, sender, r)
+	case *gamesvc.Message_Chat:
+		return t, handleChat(t.prev.env, msg.Chat, sender, r, func(text string) error {
+			return t.checkChat(sender, r, text)
+		})
+	case *gamesvc.Message_Reaction:
+		return t, handleReaction(t.prev.env, msg.Reaction, sender, r)
 	default:
 		return t, &UnknownMessageTypeError{T: message.Message}
 	}
@@ -44,23 +64,35 @@ func (t Turn) handleEndTurn(msg *gamesvc.MsgEndTurn, sender *entity.Player, r *e
 		return p.ID != sender.ID
 	})
 
-	err := sendMsgToPlayers(&gamesvc.Message{
+	sendMsgToPlayers(&gamesvc.Message{
 		Message: &gamesvc.Message_EndTurn{
 			EndTurn: msg,
 		},
 	}, players...)
-	if err != nil {
-		return t, err
-	}
 
 	team, ok := r.FindTeamWithPlayer(sender.ID)
 	if ok {
+		guesser, _ := team.OponentOf(sender.ID)
+		t.prev.match.Turns = append(t.prev.match.Turns, &gamesvc.MatchTurn{
+			TeamId:      team.ID,
+			ExplainerId: sender.ID,
+			GuesserId:   guesser.ToProto().GetId(),
+			Stats: &gamesvc.Statistics{
+				Rights: msg.Stats.GetRights(),
+				Wrongs: msg.Stats.GetWrongs(),
+			},
+		})
+
 		prevStats, ok := t.prev.stats[team.ID]
 		if ok {
-			prevStats.Rights += msg.Stats.Rights
-			prevStats.Wrongs += msg.Stats.Wrongs
+			prevStats.Rights += msg.Stats.GetRights()
+			prevStats.Wrongs += msg.Stats.GetWrongs()
 		} else {
-			prevStats = msg.Stats
+			// penalties are given only by the server
+			prevStats = &gamesvc.Statistics{
+				Rights: msg.Stats.GetRights(),
+				Wrongs: msg.Stats.GetWrongs(),
+			}
 		}
 
 		t.prev.stats[team.ID] = prevStats
@@ -69,6 +101,28 @@ func (t Turn) handleEndTurn(msg *gamesvc.MsgEndTurn, sender *entity.Player, r *e
 	return t.prev, nil
 }
 
+func (t Turn) handlePause(_ *gamesvc.MsgPause, sender *entity.Player, r *entity.Room) (Stater, error) {
+	if r.LeaderId != sender.ID {
+		return t, errors.New("only leader can pause game")
+	}
+
+	remaining := t.remaining()
+	if remaining == 0 {
+		return t, errors.New("turn deadline exceeded")
+	}
+
+	return pause(t, remaining, sender.ID, r), nil
+}
+
+// remaining returns the time left until the deadline.
+func (t Turn) remaining() time.Duration {
+	remaining := time.Until(t.turnDeadline)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
 func (t Turn) handleWord(msg *gamesvc.MsgWord, sender *entity.Player, r *entity.Room) (Stater, error) {
 	if t.prev.playerIDTurn != sender.ID {
 		return t, fmt.Errorf("only player %q can send word", t.prev.playerIDTurn)
@@ -91,11 +145,41 @@ func (t Turn) handleWord(msg *gamesvc.MsgWord, sender *entity.Player, r *entity.
 		return p.ID != oponent.ID && p.ID != sender.ID
 	})
 
-	err := sendMsgToPlayers(&gamesvc.Message{
+	sendMsgToPlayers(&gamesvc.Message{
 		Message: &gamesvc.Message_Word{Word: &gamesvc.MsgWord{
 			Word: msg.GetWord(),
 		}},
 	}, players...)
 
-	return t, err
+	t.word = msg.GetWord()
+	return t, nil
+}
+
+// checkChat stops the explainer from giving away the word in the chat. The
+// team of the explainer gets a penalty for every attempt.
+func (t Turn) checkChat(sender *entity.Player, r *entity.Room, text string) error {
+	if sender.ID != t.prev.playerIDTurn || t.word == "" {
+		return nil
+	}
+
+	opts := DefaultCheatOptions()
+	if t.prev.env != nil {
+		opts = t.prev.env.Cheat
+	}
+
+	if opts.Detector == nil || !opts.Detector.Leaks(r.Langugage, t.word, text) {
+		return nil
+	}
+
+	team, ok := r.FindTeamWithPlayer(sender.ID)
+	if ok {
+		stats, ok := t.prev.stats[team.ID]
+		if !ok {
+			stats = &gamesvc.Statistics{}
+			t.prev.stats[team.ID] = stats
+		}
+		stats.Penalties += opts.Penalty
+	}
+
+	return ErrWordLeaked
 }
@@ -0,0 +1,157 @@
+// Package health reports whether the server is alive and ready to accept
+// players, over gRPC health checking and HTTP.
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/knightpp/alias-server/internal/logging"
+	"github.com/knightpp/alias-server/internal/storage"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+type Options struct {
+	// Interval is how often storage is checked.
+	Interval time.Duration
+	// Timeout of a single storage check.
+	Timeout time.Duration
+	// Services are names of gRPC services that share readiness of the
+	// server.
+	Services []string
+}
+
+func DefaultOptions(services ...string) Options {
+	return Options{
+		Interval: 5 * time.Second,
+		Timeout:  time.Second,
+		Services: services,
+	}
+}
+
+// Checker is ready when storage is reachable and the server isn't draining.
+type Checker struct {
+	log  zerolog.Logger
+	db   storage.Pinger
+	opts Options
+
+	server *health.Server
+
+	mu       sync.Mutex
+	ready    bool
+	draining bool
+}
+
+// New returns a checker that isn't ready until the first check passes.
+func New(log zerolog.Logger, db storage.Pinger, opts Options) *Checker {
+	c := &Checker{
+		log:    logging.Component(log, "health"),
+		db:     db,
+		opts:   opts,
+		server: health.NewServer(),
+	}
+	c.setServing(healthpb.HealthCheckResponse_NOT_SERVING)
+	return c
+}
+
+// Server implements grpc.health.v1.Health.
+func (c *Checker) Server() healthpb.HealthServer {
+	return c.server
+}
+
+// Start checks storage until ctx is done.
+func (c *Checker) Start(ctx context.Context) {
+	ticker := time.NewTicker(c.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		c.Check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check pings storage and updates readiness.
+func (c *Checker) Check(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, c.opts.Timeout)
+	defer cancel()
+
+	err := c.db.Ping(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.draining {
+		return
+	}
+
+	ready := err == nil
+	if ready != c.ready {
+		if ready {
+			c.log.Info().Msg("storage is reachable, server is ready")
+		} else {
+			c.log.Err(err).Msg("storage is unreachable, server is not ready")
+		}
+	}
+	c.ready = ready
+
+	if ready {
+		c.setServing(healthpb.HealthCheckResponse_SERVING)
+	} else {
+		c.setServing(healthpb.HealthCheckResponse_NOT_SERVING)
+	}
+}
+
+// Drain makes the server not ready for good, so that load balancers stop
+// sending new players before the server stops.
+func (c *Checker) Drain() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.draining = true
+	c.ready = false
+	c.server.Shutdown()
+}
+
+func (c *Checker) Ready() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.ready
+}
+
+// Healthz responds OK while the process is running.
+func (c *Checker) Healthz(w http.ResponseWriter, _ *http.Request) {
+	_, _ = w.Write([]byte("ok\n"))
+}
+
+// Readyz responds OK if the server is ready and with 503 otherwise.
+func (c *Checker) Readyz(w http.ResponseWriter, _ *http.Request) {
+	c.mu.Lock()
+	ready, draining := c.ready, c.draining
+	c.mu.Unlock()
+
+	switch {
+	case draining:
+		http.Error(w, "draining", http.StatusServiceUnavailable)
+	case !ready:
+		http.Error(w, "storage is unreachable", http.StatusServiceUnavailable)
+	default:
+		_, _ = w.Write([]byte("ok\n"))
+	}
+}
+
+func (c *Checker) setServing(status healthpb.HealthCheckResponse_ServingStatus) {
+	c.server.SetServingStatus("", status)
+	for _, service := range c.opts.Services {
+		c.server.SetServingStatus(service, status)
+	}
+}
@@ -0,0 +1,101 @@
+// Package logging builds loggers of the server: output format, levels per
+// component, request correlation and redaction of secrets.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog"
+)
+
+type Format string
+
+const (
+	FormatJSON    Format = "json"
+	FormatConsole Format = "console"
+)
+
+type Options struct {
+	Format Format
+	Level  zerolog.Level
+	// Components overrides Level for loggers of components, e.g. "game".
+	Components map[string]zerolog.Level
+}
+
+func DefaultOptions() Options {
+	return Options{
+		Format: FormatConsole,
+		Level:  zerolog.InfoLevel,
+	}
+}
+
+var (
+	componentsMu sync.RWMutex
+	components   map[string]zerolog.Level
+)
+
+// New returns the root logger. Levels of components are set globally, so
+// that every logger passed to Component uses them.
+func New(w io.Writer, opts Options) (zerolog.Logger, error) {
+	switch opts.Format {
+	case FormatJSON:
+	case FormatConsole:
+		w = zerolog.ConsoleWriter{Out: w}
+	default:
+		return zerolog.Logger{}, fmt.Errorf("unknown log format %q", opts.Format)
+	}
+
+	componentsMu.Lock()
+	components = opts.Components
+	componentsMu.Unlock()
+
+	return zerolog.New(w).
+		With().
+		Timestamp().
+		Logger().
+		Level(opts.Level), nil
+}
+
+// Component returns a logger of the component with its level, if it was
+// configured.
+func Component(log zerolog.Logger, name string) zerolog.Logger {
+	log = log.With().Str("component", name).Logger()
+
+	componentsMu.RLock()
+	level, ok := components[name]
+	componentsMu.RUnlock()
+	if ok {
+		log = log.Level(level)
+	}
+
+	return log
+}
+
+// ParseComponents parses levels of components written as
+// "game=debug,storage=warn".
+func ParseComponents(s string) (map[string]zerolog.Level, error) {
+	levels := make(map[string]zerolog.Level)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, levelStr, ok := strings.Cut(entry, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("component level %q should be written as name=level", entry)
+		}
+
+		level, err := zerolog.ParseLevel(levelStr)
+		if err != nil {
+			return nil, fmt.Errorf("component %s: %w", name, err)
+		}
+
+		levels[name] = level
+	}
+
+	return levels, nil
+}
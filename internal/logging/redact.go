@@ -0,0 +1,57 @@
+package logging
+
+import (
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const redacted = "[REDACTED]"
+
+// Redact returns a copy of msg with secrets replaced, so that it can be
+// logged.
+func Redact(msg proto.Message) proto.Message {
+	if msg == nil {
+		return nil
+	}
+
+	msg = proto.Clone(msg)
+	redact(msg.ProtoReflect())
+	return msg
+}
+
+func redact(m protoreflect.Message) {
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case fd.Kind() == protoreflect.StringKind && !fd.IsList() && !fd.IsMap():
+			if isSecret(fd.Name()) {
+				m.Set(fd, protoreflect.ValueOfString(redacted))
+			}
+		case fd.IsList() && fd.Kind() == protoreflect.MessageKind:
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				redact(list.Get(i).Message())
+			}
+		case fd.IsMap() && fd.MapValue().Kind() == protoreflect.MessageKind:
+			v.Map().Range(func(_ protoreflect.MapKey, v protoreflect.Value) bool {
+				redact(v.Message())
+				return true
+			})
+		case fd.Kind() == protoreflect.MessageKind:
+			redact(v.Message())
+		}
+		return true
+	})
+}
+
+// secrets are names of fields with secrets. Not every token is a secret,
+// e.g. page_token.
+var secrets = map[protoreflect.Name]struct{}{
+	"token":      {},
+	"auth_token": {},
+	"password":   {},
+}
+
+func isSecret(name protoreflect.Name) bool {
+	_, ok := secrets[name]
+	return ok
+}
@@ -0,0 +1,80 @@
+package logging_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	loginsvc "github.com/knightpp/alias-proto/go/login_service"
+	"github.com/knightpp/alias-server/internal/logging"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func TestRedact(t *testing.T) {
+	password := "secret"
+	redacted := "[REDACTED]"
+
+	tests := []struct {
+		name string
+		msg  proto.Message
+		want proto.Message
+	}{
+		{
+			name: "password",
+			msg:  &gamesvc.CreateRoomRequest{Name: "room", Password: &password},
+			want: &gamesvc.CreateRoomRequest{Name: "room", Password: &redacted},
+		},
+		{
+			name: "unset password",
+			msg:  &gamesvc.CreateRoomRequest{Name: "room"},
+			want: &gamesvc.CreateRoomRequest{Name: "room"},
+		},
+		{
+			name: "token",
+			msg:  &loginsvc.VerifyTokenRequest{Token: "secret"},
+			want: &loginsvc.VerifyTokenRequest{Token: redacted},
+		},
+		{
+			name: "auth token",
+			msg:  &loginsvc.Account{Id: "id", AuthToken: "secret"},
+			want: &loginsvc.Account{Id: "id", AuthToken: redacted},
+		},
+		{
+			name: "page token is not a secret",
+			msg:  &gamesvc.ListRoomsRequest{PageToken: "50"},
+			want: &gamesvc.ListRoomsRequest{PageToken: "50"},
+		},
+		{
+			name: "nested message",
+			msg: &gamesvc.Message{
+				Message: &gamesvc.Message_UpdateRoom{
+					UpdateRoom: &gamesvc.UpdateRoom{Password: &password},
+				},
+			},
+			want: &gamesvc.Message{
+				Message: &gamesvc.Message_UpdateRoom{
+					UpdateRoom: &gamesvc.UpdateRoom{Password: &redacted},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := proto.Clone(tt.msg)
+
+			got := logging.Redact(tt.msg)
+			assert.Empty(t, cmpDiff(tt.want, got))
+			assert.Empty(t, cmpDiff(original, tt.msg), "the message is not changed")
+		})
+	}
+}
+
+func TestRedactNil(t *testing.T) {
+	assert.Nil(t, logging.Redact(nil))
+}
+
+func cmpDiff(want, got proto.Message) string {
+	return cmp.Diff(want, got, protocmp.Transform())
+}
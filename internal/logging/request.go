@@ -0,0 +1,76 @@
+package logging
+
+import (
+	"context"
+
+	"github.com/knightpp/alias-server/internal/uuidgen"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// RequestIDKey is a metadata key with ID of a request. A client can set it
+// to correlate its logs with the server, otherwise the server generates one.
+const RequestIDKey = "x-request-id"
+
+type requestIDKey struct{}
+
+// RequestID returns ID of the request or an empty string.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// UnaryServerInterceptor adds ID of the request to ctx and to the logger
+// in ctx, which is returned by zerolog.Ctx.
+func UnaryServerInterceptor(log zerolog.Logger) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		_ *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		ctx = withRequestID(ctx, log)
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor is the same as UnaryServerInterceptor for
+// streams.
+func StreamServerInterceptor(log zerolog.Logger) grpc.StreamServerInterceptor {
+	return func(
+		srv any,
+		ss grpc.ServerStream,
+		_ *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		ctx := withRequestID(ss.Context(), log)
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func withRequestID(ctx context.Context, log zerolog.Logger) context.Context {
+	var id string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ids := md.Get(RequestIDKey); len(ids) == 1 && len(ids[0]) <= 64 {
+			id = ids[0]
+		}
+	}
+	if id == "" {
+		id = uuidgen.NewString()
+	}
+
+	_ = grpc.SetHeader(ctx, metadata.Pairs(RequestIDKey, id))
+
+	ctx = context.WithValue(ctx, requestIDKey{}, id)
+	return log.With().Str("request-id", id).Logger().WithContext(ctx)
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
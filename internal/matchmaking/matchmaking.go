@@ -0,0 +1,404 @@
+package matchmaking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	"github.com/knightpp/alias-server/internal/logging"
+	"github.com/knightpp/alias-server/internal/rating"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// MinTeams is the least number of teams in a match.
+	MinTeams = 2
+	// MaxTeams is the largest number of teams a player can ask for.
+	MaxTeams = 8
+	// MaxGroupSize is the largest group that queues together. A group always
+	// plays in one team, so it cannot be larger than a team.
+	MaxGroupSize = 2
+)
+
+var (
+	ErrAlreadyQueued = errors.New("player is already in the queue")
+	ErrTimeout       = errors.New("could not find a match in time")
+)
+
+type Ratings interface {
+	GetRatings(ctx context.Context, language string, playerIDs []string) (map[string]float64, error)
+}
+
+type RoomCreator interface {
+	// CreateMatchedRoom creates a room where every team is reserved for the
+	// given players.
+	CreateMatchedRoom(req *gamesvc.CreateRoomRequest, teams [][]*gamesvc.Player) (roomID string)
+}
+
+type Options struct {
+	// Interval is how often the queue tries to form matches.
+	Interval time.Duration
+	// Timeout is how long a request waits for a match at most.
+	Timeout time.Duration
+	// PartialAfter is how long the oldest request waits for a full match
+	// before a match with fewer teams is formed.
+	PartialAfter time.Duration
+	// RatingRange is the largest rating difference between the oldest request
+	// and others in a match. It grows by RatingRangeGrowth every second the
+	// oldest request waits.
+	RatingRange       float64
+	RatingRangeGrowth float64
+}
+
+func DefaultOptions() Options {
+	return Options{
+		Interval:          time.Second,
+		Timeout:           2 * time.Minute,
+		PartialAfter:      30 * time.Second,
+		RatingRange:       100,
+		RatingRangeGrowth: 10,
+	}
+}
+
+type Request struct {
+	// Players queue together and play in one team.
+	Players  []*gamesvc.Player
+	Language string
+	// Teams is the desired number of teams.
+	Teams int
+}
+
+type ticket struct {
+	Request
+
+	rating     float64
+	ratings    map[string]float64
+	enqueuedAt time.Time
+	found      chan string
+}
+
+func (t *ticket) size() int {
+	return len(t.Players)
+}
+
+type bucketKey struct {
+	language string
+	teams    int
+}
+
+type Queue struct {
+	log     zerolog.Logger
+	ratings Ratings
+	rooms   RoomCreator
+	opts    Options
+
+	actorChan chan func()
+	// tickets are ordered by enqueue time
+	tickets []*ticket
+	queued  map[string]*ticket
+}
+
+func New(log zerolog.Logger, ratings Ratings, rooms RoomCreator, opts Options) *Queue {
+	return &Queue{
+		log:       logging.Component(log, "matchmaking"),
+		ratings:   ratings,
+		rooms:     rooms,
+		opts:      opts,
+		actorChan: make(chan func()),
+		queued:    make(map[string]*ticket),
+	}
+}
+
+func (q *Queue) Start(ctx context.Context) {
+	ticker := time.NewTicker(q.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case fn := <-q.actorChan:
+			fn()
+		case now := <-ticker.C:
+			q.match(now)
+		}
+	}
+}
+
+// Enqueue waits until the request is matched and returns ID of the created
+// room. The request leaves the queue when ctx is done or after timeout.
+func (q *Queue) Enqueue(ctx context.Context, req Request) (string, error) {
+	if len(req.Players) == 0 || len(req.Players) > MaxGroupSize {
+		return "", fmt.Errorf("group size should be from 1 to %d", MaxGroupSize)
+	}
+	if req.Teams < MinTeams || req.Teams > MaxTeams {
+		return "", fmt.Errorf("number of teams should be from %d to %d", MinTeams, MaxTeams)
+	}
+
+	ids := make([]string, len(req.Players))
+	for i, p := range req.Players {
+		ids[i] = p.Id
+	}
+
+	ratings, err := q.ratings.GetRatings(ctx, req.Language, ids)
+	if err != nil {
+		return "", fmt.Errorf("get ratings: %w", err)
+	}
+
+	t := &ticket{
+		Request:    req,
+		rating:     rating.Average(valuesOf(ratings, ids)...),
+		ratings:    ratings,
+		enqueuedAt: time.Now(),
+		// buffered to not block the queue if requester has already left
+		found: make(chan string, 1),
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, q.opts.Timeout)
+	defer cancel()
+
+	err = q.do(ctx, func() error {
+		return q.add(t)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	select {
+	case roomID := <-t.found:
+		return roomID, nil
+	case <-ctx.Done():
+		// the queue may be gone already, so don't wait for it forever
+		removeCtx, cancel := context.WithTimeout(context.Background(), q.opts.Interval)
+		defer cancel()
+
+		_ = q.do(removeCtx, func() error {
+			q.remove(t)
+			return nil
+		})
+
+		select {
+		case roomID := <-t.found:
+			// matched just before leaving
+			return roomID, nil
+		default:
+		}
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", ErrTimeout
+		}
+		return "", ctx.Err()
+	}
+}
+
+// do runs fn inside the queue loop and waits for the result.
+func (q *Queue) do(ctx context.Context, fn func() error) error {
+	errChan := make(chan error, 1)
+	select {
+	case q.actorChan <- func() { errChan <- fn() }:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return <-errChan
+}
+
+func (q *Queue) add(t *ticket) error {
+	for _, p := range t.Players {
+		if _, ok := q.queued[p.Id]; ok {
+			return ErrAlreadyQueued
+		}
+	}
+
+	for _, p := range t.Players {
+		q.queued[p.Id] = t
+	}
+	q.tickets = append(q.tickets, t)
+
+	return nil
+}
+
+func (q *Queue) remove(tickets ...*ticket) {
+	for _, t := range tickets {
+		for _, p := range t.Players {
+			delete(q.queued, p.Id)
+		}
+	}
+
+	q.tickets = without(q.tickets, tickets)
+}
+
+func (q *Queue) match(now time.Time) {
+	buckets := make(map[bucketKey][]*ticket)
+	var keys []bucketKey
+	for _, t := range q.tickets {
+		key := bucketKey{language: t.Language, teams: t.Teams}
+		if _, ok := buckets[key]; !ok {
+			keys = append(keys, key)
+		}
+		buckets[key] = append(buckets[key], t)
+	}
+
+	for _, key := range keys {
+		tickets := buckets[key]
+		for len(tickets) != 0 {
+			picked := q.pick(now, key.teams, tickets)
+			if picked == nil {
+				break
+			}
+
+			q.form(key.language, picked)
+			tickets = without(tickets, picked)
+		}
+	}
+}
+
+// pick selects tickets for a single match. Tickets are picked around the
+// rating of the oldest ticket; if it can't be matched yet, the next ticket
+// is tried, so a single hard to match ticket doesn't hold the queue.
+func (q *Queue) pick(now time.Time, teams int, tickets []*ticket) []*ticket {
+	for _, anchor := range tickets {
+		picked := q.pickAround(now, teams, anchor, tickets)
+		if picked != nil {
+			return picked
+		}
+	}
+
+	return nil
+}
+
+// pickAround selects tickets for a match of the anchor ticket.
+func (q *Queue) pickAround(now time.Time, teams int, anchor *ticket, tickets []*ticket) []*ticket {
+	waited := now.Sub(anchor.enqueuedAt)
+	maxDiff := q.opts.RatingRange + q.opts.RatingRangeGrowth*waited.Seconds()
+
+	candidates := make([]*ticket, 0, len(tickets))
+	for _, t := range tickets {
+		if math.Abs(t.rating-anchor.rating) <= maxDiff {
+			candidates = append(candidates, t)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return math.Abs(candidates[i].rating-anchor.rating) < math.Abs(candidates[j].rating-anchor.rating)
+	})
+
+	picked := pickTeams(teams, anchor, candidates)
+	if picked != nil || waited < q.opts.PartialAfter {
+		return picked
+	}
+
+	for n := teams - 1; n >= MinTeams; n-- {
+		picked = pickTeams(n, anchor, candidates)
+		if picked != nil {
+			return picked
+		}
+	}
+
+	return nil
+}
+
+// form creates a room for picked tickets and notifies them.
+func (q *Queue) form(language string, picked []*ticket) {
+	var (
+		teams   [][]*gamesvc.Player
+		singles []*gamesvc.Player
+		ratings = make(map[string]float64)
+	)
+	for _, t := range picked {
+		for id, r := range t.ratings {
+			ratings[id] = r
+		}
+
+		if t.size() == MaxGroupSize {
+			teams = append(teams, t.Players)
+		} else {
+			singles = append(singles, t.Players...)
+		}
+	}
+
+	// the strongest player plays with the weakest one to balance teams
+	sort.SliceStable(singles, func(i, j int) bool {
+		return ratings[singles[i].Id] > ratings[singles[j].Id]
+	})
+	for i := 0; i < len(singles)/2; i++ {
+		teams = append(teams, []*gamesvc.Player{singles[i], singles[len(singles)-1-i]})
+	}
+
+	roomID := q.rooms.CreateMatchedRoom(&gamesvc.CreateRoomRequest{
+		Name:      "Quick match",
+		IsPublic:  false,
+		Langugage: language,
+	}, teams)
+
+	q.log.Info().
+		Str("room-id", roomID).
+		Int("teams", len(teams)).
+		Msg("formed a match")
+
+	q.remove(picked...)
+	for _, t := range picked {
+		t.found <- roomID
+	}
+}
+
+// pickTeams picks tickets for exactly n teams preferring whole groups. The
+// anchor is always picked.
+func pickTeams(n int, anchor *ticket, tickets []*ticket) []*ticket {
+	var groups, singles []*ticket
+	for _, t := range tickets {
+		switch {
+		case t == anchor:
+		case t.size() == MaxGroupSize:
+			groups = append(groups, t)
+		default:
+			singles = append(singles, t)
+		}
+	}
+
+	maxGroups := n
+	if anchor.size() == MaxGroupSize {
+		groups = append([]*ticket{anchor}, groups...)
+	} else {
+		singles = append([]*ticket{anchor}, singles...)
+		// the anchor needs a team of singles
+		maxGroups--
+	}
+
+	if len(groups) > maxGroups {
+		groups = groups[:maxGroups]
+	}
+
+	needSingles := (n - len(groups)) * MaxGroupSize
+	if needSingles > len(singles) {
+		return nil
+	}
+
+	return append(groups, singles[:needSingles]...)
+}
+
+func without(tickets, removed []*ticket) []*ticket {
+	isRemoved := make(map[*ticket]bool, len(removed))
+	for _, t := range removed {
+		isRemoved[t] = true
+	}
+
+	kept := make([]*ticket, 0, len(tickets))
+	for _, t := range tickets {
+		if !isRemoved[t] {
+			kept = append(kept, t)
+		}
+	}
+	return kept
+}
+
+func valuesOf(m map[string]float64, keys []string) []float64 {
+	values := make([]float64, len(keys))
+	for i, k := range keys {
+		values[i] = m[k]
+	}
+	return values
+}
@@ -0,0 +1,272 @@
+package matchmaking
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var epoch = time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func newTicket(name string, size int, rating float64, waited time.Duration) *ticket {
+	players := make([]*gamesvc.Player, size)
+	for i := range players {
+		players[i] = &gamesvc.Player{Id: fmt.Sprintf("%s-%d", name, i)}
+	}
+
+	return &ticket{
+		Request: Request{
+			Players: players,
+			Teams:   2,
+		},
+		rating:     rating,
+		enqueuedAt: epoch.Add(-waited),
+		found:      make(chan string, 1),
+	}
+}
+
+func names(tickets []*ticket) []string {
+	var ids []string
+	for _, t := range tickets {
+		ids = append(ids, t.Players[0].Id)
+	}
+	return ids
+}
+
+func TestPickTeams(t *testing.T) {
+	var (
+		single1 = newTicket("single1", 1, 0, 0)
+		single2 = newTicket("single2", 1, 0, 0)
+		single3 = newTicket("single3", 1, 0, 0)
+		group1  = newTicket("group1", 2, 0, 0)
+		group2  = newTicket("group2", 2, 0, 0)
+		group3  = newTicket("group3", 2, 0, 0)
+	)
+
+	tests := []struct {
+		name    string
+		teams   int
+		anchor  *ticket
+		tickets []*ticket
+		want    []*ticket
+	}{
+		{
+			name:    "groups",
+			teams:   2,
+			anchor:  group1,
+			tickets: []*ticket{group1, group2, group3},
+			want:    []*ticket{group1, group2},
+		},
+		{
+			name:    "singles",
+			teams:   2,
+			anchor:  single1,
+			tickets: []*ticket{single1, single2, single3, group1},
+			want:    []*ticket{group1, single1, single2},
+		},
+		{
+			name:    "single anchor is not skipped for groups",
+			teams:   2,
+			anchor:  single1,
+			tickets: []*ticket{single1, single2, group1, group2},
+			want:    []*ticket{group1, single1, single2},
+		},
+		{
+			name:    "group anchor is picked first",
+			teams:   2,
+			anchor:  group3,
+			tickets: []*ticket{group1, group2, group3},
+			want:    []*ticket{group3, group1},
+		},
+		{
+			name:    "single anchor without a partner",
+			teams:   2,
+			anchor:  single1,
+			tickets: []*ticket{single1, group1, group2},
+			want:    nil,
+		},
+		{
+			name:    "not enough tickets",
+			teams:   3,
+			anchor:  group1,
+			tickets: []*ticket{group1, single1, single2},
+			want:    nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := pickTeams(tt.teams, tt.anchor, tt.tickets)
+			assert.Equal(t, names(tt.want), names(got))
+		})
+	}
+}
+
+func TestPick(t *testing.T) {
+	opts := Options{
+		PartialAfter:      30 * time.Second,
+		RatingRange:       100,
+		RatingRangeGrowth: 10,
+	}
+
+	var (
+		outlier = newTicket("outlier", 2, 2000, 10*time.Second)
+		group1  = newTicket("group1", 2, 1000, 5*time.Second)
+		group2  = newTicket("group2", 2, 1050, 5*time.Second)
+		group3  = newTicket("group3", 2, 1140, 5*time.Second)
+		old     = newTicket("old", 2, 1500, 40*time.Second)
+	)
+
+	tests := []struct {
+		name    string
+		teams   int
+		tickets []*ticket
+		want    []*ticket
+	}{
+		{
+			name:    "close ratings",
+			teams:   2,
+			tickets: []*ticket{group1, group2},
+			want:    []*ticket{group1, group2},
+		},
+		{
+			name:    "closest ratings first",
+			teams:   2,
+			tickets: []*ticket{group1, group3, group2},
+			want:    []*ticket{group1, group2},
+		},
+		{
+			name:    "range grows with waiting",
+			teams:   2,
+			tickets: []*ticket{group1, group3},
+			want:    []*ticket{group1, group3},
+		},
+		{
+			name:    "unmatched oldest ticket doesn't hold others",
+			teams:   2,
+			tickets: []*ticket{outlier, group1, group2},
+			want:    []*ticket{group1, group2},
+		},
+		{
+			name:    "partial match after waiting",
+			teams:   3,
+			tickets: []*ticket{old, group3},
+			want:    []*ticket{old, group3},
+		},
+		{
+			name:    "no partial match before waiting",
+			teams:   3,
+			tickets: []*ticket{group1, group2},
+			want:    nil,
+		},
+		{
+			name:    "ratings too far apart",
+			teams:   2,
+			tickets: []*ticket{outlier, group1},
+			want:    nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := &Queue{opts: opts}
+			got := q.pick(epoch, tt.teams, tt.tickets)
+			assert.Equal(t, names(tt.want), names(got))
+		})
+	}
+}
+
+type fakeRatings map[string]float64
+
+func (f fakeRatings) GetRatings(_ context.Context, _ string, ids []string) (map[string]float64, error) {
+	ratings := make(map[string]float64, len(ids))
+	for _, id := range ids {
+		ratings[id] = f[id]
+	}
+	return ratings, nil
+}
+
+type fakeRooms struct {
+	mu    sync.Mutex
+	teams [][][]*gamesvc.Player
+}
+
+func (f *fakeRooms) CreateMatchedRoom(_ *gamesvc.CreateRoomRequest, teams [][]*gamesvc.Player) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.teams = append(f.teams, teams)
+	return fmt.Sprintf("room-%d", len(f.teams))
+}
+
+func TestQueueBalancesSingles(t *testing.T) {
+	ratings := fakeRatings{"a": 1000, "b": 1030, "c": 1060, "d": 1090}
+	rooms := &fakeRooms{}
+	opts := DefaultOptions()
+	opts.Interval = 10 * time.Millisecond
+
+	q := New(zerolog.Nop(), ratings, rooms, opts)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go q.Start(ctx)
+
+	var wg sync.WaitGroup
+	roomIDs := make([]string, len(ratings))
+	for i, id := range []string{"a", "b", "c", "d"} {
+		i, id := i, id
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			roomID, err := q.Enqueue(ctx, Request{
+				Players: []*gamesvc.Player{{Id: id}},
+				Teams:   2,
+			})
+			assert.NoError(t, err)
+			roomIDs[i] = roomID
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, []string{"room-1", "room-1", "room-1", "room-1"}, roomIDs)
+	require.Len(t, rooms.teams, 1)
+
+	var teams [][]string
+	for _, team := range rooms.teams[0] {
+		teams = append(teams, []string{team[0].Id, team[1].Id})
+	}
+	// the strongest player plays with the weakest one
+	assert.ElementsMatch(t, [][]string{{"d", "a"}, {"c", "b"}}, teams)
+}
+
+func TestQueueRejectsDuplicates(t *testing.T) {
+	q := New(zerolog.Nop(), fakeRatings{}, &fakeRooms{}, DefaultOptions())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go q.Start(ctx)
+
+	req := Request{
+		Players: []*gamesvc.Player{{Id: "a"}},
+		Teams:   3,
+	}
+	go func() {
+		_, _ = q.Enqueue(ctx, req)
+	}()
+
+	require.Eventually(t, func() bool {
+		var queued bool
+		_ = q.do(ctx, func() error {
+			_, queued = q.queued["a"]
+			return nil
+		})
+		return queued
+	}, time.Second, 10*time.Millisecond)
+
+	_, err := q.Enqueue(ctx, req)
+	assert.ErrorIs(t, err, ErrAlreadyQueued)
+}
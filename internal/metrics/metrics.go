@@ -0,0 +1,117 @@
+// Package metrics collects Prometheus metrics of the server.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "alias"
+
+// Registry has every metric of the server together with Go runtime and
+// process metrics.
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+var (
+	RoomsActive = factory.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "rooms_active",
+		Help:      "Number of running rooms.",
+	})
+	RoomsByState = factory.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "rooms_by_state",
+		Help:      "Number of running rooms in every state.",
+	}, []string{"state"})
+	RoomsReaped = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rooms_reaped_total",
+		Help:      "Number of rooms closed by the janitor, by reason.",
+	}, []string{"reason"})
+	PlayersConnected = factory.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "players_connected",
+		Help:      "Number of players connected to rooms.",
+	})
+
+	MessagesHandled = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "messages_handled_total",
+		Help:      "Number of messages from players handled by rooms.",
+	}, []string{"type"})
+	UnknownMessages = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "unknown_messages_total",
+		Help:      "Number of messages that are not expected in the state of a room.",
+	}, []string{"state", "type"})
+	SendErrors = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "send_errors_total",
+		Help:      "Number of messages that could not be sent to players.",
+	})
+	MessagesDropped = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "messages_dropped_total",
+		Help:      "Number of messages dropped because players were too slow to receive them.",
+	})
+	SlowConsumers = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "slow_consumers_total",
+		Help:      "Number of players disconnected because they were too slow to receive messages.",
+	})
+	IdleKicks = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "idle_kicks_total",
+		Help:      "Number of players kicked from lobbies for inactivity.",
+	})
+
+	MessagesRateLimited = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "messages_rate_limited_total",
+		Help:      "Number of messages from players rejected by rate limits.",
+	}, []string{"type"})
+	RateLimited = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_total",
+		Help:      "Number of RPCs rejected by rate limits.",
+	}, []string{"method"})
+
+	StorageLatency = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "storage_duration_seconds",
+		Help:      "Latency of storage operations.",
+		Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 14),
+	}, []string{"operation"})
+
+	GRPCHandled = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "grpc_server_handled_total",
+		Help:      "Number of completed RPCs.",
+	}, []string{"method", "code"})
+	GRPCLatency = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "grpc_server_handling_seconds",
+		Help:      "Duration of RPCs until they complete.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler serves metrics in the Prometheus format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{
+		Registry: Registry,
+	})
+}
@@ -0,0 +1,35 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	"github.com/knightpp/alias-server/internal/storage"
+)
+
+var _ storage.Storage = instrumentedStorage{}
+
+type instrumentedStorage struct {
+	storage.Storage
+}
+
+// InstrumentStorage measures latency of player lookups, which happen on
+// every authenticated RPC.
+func InstrumentStorage(db storage.Storage) storage.Storage {
+	return instrumentedStorage{Storage: db}
+}
+
+func (s instrumentedStorage) GetPlayer(ctx context.Context, token string) (*gamesvc.Player, error) {
+	defer observeStorage("get_player", time.Now())
+	return s.Storage.GetPlayer(ctx, token)
+}
+
+func (s instrumentedStorage) SetPlayer(ctx context.Context, token string, p *gamesvc.Player) error {
+	defer observeStorage("set_player", time.Now())
+	return s.Storage.SetPlayer(ctx, token, p)
+}
+
+func observeStorage(operation string, start time.Time) {
+	StorageLatency.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,88 @@
+// Package notification delivers notifications to players who are connected
+// to the notifications stream.
+package notification
+
+import (
+	"sync"
+
+	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	"github.com/knightpp/alias-server/internal/logging"
+	"github.com/rs/zerolog"
+)
+
+// bufferSize is how many notifications wait for a slow subscriber before new
+// ones are dropped.
+const bufferSize = 16
+
+type subscriber struct {
+	c chan *gamesvc.Notification
+}
+
+type Hub struct {
+	log zerolog.Logger
+
+	mu sync.Mutex
+	// subscribers maps player ID to their streams, a player may be connected
+	// from several devices
+	subscribers map[string]map[*subscriber]struct{}
+}
+
+func NewHub(log zerolog.Logger) *Hub {
+	return &Hub{
+		log:         logging.Component(log, "notification"),
+		subscribers: make(map[string]map[*subscriber]struct{}),
+	}
+}
+
+// Subscribe returns a channel with notifications for the player. Call
+// unsubscribe when the channel is no longer read.
+func (h *Hub) Subscribe(playerID string) (c <-chan *gamesvc.Notification, unsubscribe func()) {
+	sub := &subscriber{
+		c: make(chan *gamesvc.Notification, bufferSize),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	subs, ok := h.subscribers[playerID]
+	if !ok {
+		subs = make(map[*subscriber]struct{})
+		h.subscribers[playerID] = subs
+	}
+	subs[sub] = struct{}{}
+
+	var once sync.Once
+	return sub.c, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+
+			delete(subs, sub)
+			if len(subs) == 0 {
+				delete(h.subscribers, playerID)
+			}
+		})
+	}
+}
+
+// Publish sends the notification to every stream of the player. It reports
+// whether the player received it on at least one stream.
+func (h *Hub) Publish(playerID string, n *gamesvc.Notification) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var delivered bool
+	for sub := range h.subscribers[playerID] {
+		select {
+		case sub.c <- n:
+			delivered = true
+		default:
+			h.log.Warn().
+				Str("player-id", playerID).
+				Type("notification", n.Notification).
+				Msg("dropped notification for slow subscriber")
+		}
+	}
+
+	return delivered
+}
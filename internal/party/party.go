@@ -0,0 +1,202 @@
+// Package party keeps groups of players who join rooms and matchmake
+// together.
+package party
+
+import (
+	"errors"
+	"sync"
+
+	clone "github.com/huandu/go-clone/generic"
+	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	"github.com/knightpp/alias-server/internal/uuidgen"
+)
+
+// MaxSize is the largest party. A party plays in one team, so it cannot be
+// larger than a team.
+const MaxSize = 2
+
+var (
+	ErrNotFound   = errors.New("party not found")
+	ErrFull       = errors.New("party is full")
+	ErrNotLeader  = errors.New("player is not a party leader")
+	ErrInParty    = errors.New("player is already in a party")
+	ErrNotInvited = errors.New("player is not invited to the party")
+)
+
+type party struct {
+	id       string
+	leaderID string
+	members  []*gamesvc.Player
+	invited  map[string]bool
+}
+
+func (p *party) toProto() *gamesvc.Party {
+	return &gamesvc.Party{
+		Id:       p.id,
+		LeaderId: p.leaderID,
+		Members:  clone.Clone(p.members),
+	}
+}
+
+type Manager struct {
+	mu      sync.Mutex
+	parties map[string]*party
+	// byPlayer maps ID of a player to their party
+	byPlayer map[string]*party
+	// pending maps ID of a leader to the party nobody has joined yet. The
+	// leader isn't in the party until someone joins it.
+	pending map[string]*party
+}
+
+func NewManager() *Manager {
+	return &Manager{
+		parties:  make(map[string]*party),
+		byPlayer: make(map[string]*party),
+		pending:  make(map[string]*party),
+	}
+}
+
+// Invite invites the player to the party of the leader. A party is created if
+// the leader isn't in one, it's formed when the first player joins it.
+func (m *Manager) Invite(leader *gamesvc.Player, playerID string) (*gamesvc.Party, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, ok := m.byPlayer[leader.Id]
+	if !ok {
+		p, ok = m.pending[leader.Id]
+	}
+	if !ok {
+		p = &party{
+			id:       uuidgen.NewString(),
+			leaderID: leader.Id,
+			members:  []*gamesvc.Player{clone.Clone(leader)},
+			invited:  make(map[string]bool),
+		}
+		m.parties[p.id] = p
+		m.pending[leader.Id] = p
+	}
+
+	if p.leaderID != leader.Id {
+		return nil, ErrNotLeader
+	}
+	if len(p.members) >= MaxSize {
+		return nil, ErrFull
+	}
+
+	p.invited[playerID] = true
+
+	return p.toProto(), nil
+}
+
+// Join adds the player to the party they were invited to.
+func (m *Manager) Join(player *gamesvc.Player, partyID string) (*gamesvc.Party, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, ok := m.parties[partyID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if _, ok := m.byPlayer[player.Id]; ok {
+		return nil, ErrInParty
+	}
+	if !p.invited[player.Id] {
+		return nil, ErrNotInvited
+	}
+	if len(p.members) >= MaxSize {
+		return nil, ErrFull
+	}
+
+	// the party is formed, the leader can't join other parties now
+	if m.pending[p.leaderID] == p {
+		delete(m.pending, p.leaderID)
+		m.byPlayer[p.leaderID] = p
+	}
+	// a party the player invited others to is void now
+	m.removePending(player.Id)
+
+	delete(p.invited, player.Id)
+	p.members = append(p.members, clone.Clone(player))
+	m.byPlayer[player.Id] = p
+
+	return p.toProto(), nil
+}
+
+func (m *Manager) removePending(leaderID string) {
+	p, ok := m.pending[leaderID]
+	if !ok {
+		return
+	}
+
+	delete(m.pending, leaderID)
+	delete(m.parties, p.id)
+}
+
+// Leave removes the player from their party. If the leader leaves, the next
+// member leads the party. A party with a single member left is disbanded.
+// It returns the party as it was after the player left.
+func (m *Manager) Leave(playerID string) (*gamesvc.Party, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, ok := m.byPlayer[playerID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	delete(m.byPlayer, playerID)
+	members := p.members[:0]
+	for _, member := range p.members {
+		if member.Id != playerID {
+			members = append(members, member)
+		}
+	}
+	p.members = members
+
+	if p.leaderID == playerID && len(p.members) != 0 {
+		p.leaderID = p.members[0].Id
+	}
+
+	if len(p.members) < 2 {
+		for _, member := range p.members {
+			delete(m.byPlayer, member.Id)
+		}
+		delete(m.parties, p.id)
+	}
+
+	return p.toProto(), nil
+}
+
+// Get returns the party of the player.
+func (m *Manager) Get(playerID string) (*gamesvc.Party, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, ok := m.byPlayer[playerID]
+	if !ok {
+		return nil, false
+	}
+
+	return p.toProto(), true
+}
+
+// Followers returns members who follow the player if they lead a party.
+func (m *Manager) Followers(playerID string) []*gamesvc.Player {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, ok := m.byPlayer[playerID]
+	if !ok || p.leaderID != playerID {
+		return nil
+	}
+
+	var followers []*gamesvc.Player
+	for _, member := range p.members {
+		if member.Id != playerID {
+			followers = append(followers, clone.Clone(member))
+		}
+	}
+
+	return followers
+}
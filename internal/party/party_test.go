@@ -0,0 +1,119 @@
+package party_test
+
+import (
+	"testing"
+
+	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	"github.com/knightpp/alias-server/internal/party"
+	"github.com/knightpp/alias-server/internal/uuidgen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	// parties of a test need distinct IDs
+	uuidgen.SetGlobal(uuidgen.NewGoogleUUID())
+}
+
+func player(id string) *gamesvc.Player {
+	return &gamesvc.Player{Id: id, Name: "player " + id}
+}
+
+func TestInviteDoesNotFormParty(t *testing.T) {
+	m := party.NewManager()
+
+	invite, err := m.Invite(player("leader"), "member")
+	require.NoError(t, err)
+	assert.Equal(t, "leader", invite.LeaderId)
+
+	_, ok := m.Get("leader")
+	assert.False(t, ok, "the leader is not in a party until someone joins")
+}
+
+func TestJoinFormsParty(t *testing.T) {
+	m := party.NewManager()
+
+	invite, err := m.Invite(player("leader"), "member")
+	require.NoError(t, err)
+
+	p, err := m.Join(player("member"), invite.Id)
+	require.NoError(t, err)
+	assert.Len(t, p.Members, 2)
+
+	p, ok := m.Get("leader")
+	require.True(t, ok)
+	assert.Equal(t, invite.Id, p.Id)
+
+	followers := m.Followers("leader")
+	require.Len(t, followers, 1)
+	assert.Equal(t, "member", followers[0].Id)
+}
+
+func TestInviteAgainKeepsParty(t *testing.T) {
+	m := party.NewManager()
+
+	first, err := m.Invite(player("leader"), "a")
+	require.NoError(t, err)
+	second, err := m.Invite(player("leader"), "b")
+	require.NoError(t, err)
+
+	assert.Equal(t, first.Id, second.Id)
+}
+
+func TestLeaderWithPendingInviteJoinsAnotherParty(t *testing.T) {
+	m := party.NewManager()
+
+	pending, err := m.Invite(player("leader"), "member")
+	require.NoError(t, err)
+
+	other, err := m.Invite(player("host"), "leader")
+	require.NoError(t, err)
+
+	_, err = m.Join(player("leader"), other.Id)
+	require.NoError(t, err)
+
+	_, err = m.Join(player("member"), pending.Id)
+	assert.ErrorIs(t, err, party.ErrNotFound)
+}
+
+func TestJoinErrors(t *testing.T) {
+	m := party.NewManager()
+
+	_, err := m.Join(player("member"), "unknown")
+	assert.ErrorIs(t, err, party.ErrNotFound)
+
+	invite, err := m.Invite(player("leader"), "member")
+	require.NoError(t, err)
+
+	_, err = m.Join(player("stranger"), invite.Id)
+	assert.ErrorIs(t, err, party.ErrNotInvited)
+
+	_, err = m.Invite(player("leader"), "late")
+	require.NoError(t, err)
+	_, err = m.Join(player("member"), invite.Id)
+	require.NoError(t, err)
+
+	_, err = m.Join(player("late"), invite.Id)
+	assert.ErrorIs(t, err, party.ErrFull)
+
+	_, err = m.Invite(player("member"), "late")
+	assert.ErrorIs(t, err, party.ErrNotLeader)
+}
+
+func TestLeaveDisbandsParty(t *testing.T) {
+	m := party.NewManager()
+
+	invite, err := m.Invite(player("leader"), "member")
+	require.NoError(t, err)
+	_, err = m.Join(player("member"), invite.Id)
+	require.NoError(t, err)
+
+	_, err = m.Leave("leader")
+	require.NoError(t, err)
+
+	_, ok := m.Get("member")
+	assert.False(t, ok)
+
+	_, err = m.Leave("member")
+	assert.ErrorIs(t, err, party.ErrNotFound)
+}
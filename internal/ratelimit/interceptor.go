@@ -0,0 +1,165 @@
+package ratelimit
+
+import (
+	"context"
+	"net"
+	"time"
+
+	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	loginsvc "github.com/knightpp/alias-proto/go/login_service"
+	"github.com/knightpp/alias-proto/go/mdkey"
+	"github.com/knightpp/alias-server/internal/metrics"
+	"github.com/rs/zerolog"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type Options struct {
+	// Default limits every method without its own limit, the methods share
+	// the bucket.
+	Default Limit
+	// Methods limits methods by full names, e.g.
+	// /game_service.GameService/CreateRoom.
+	Methods map[string]Limit
+}
+
+func DefaultOptions() Options {
+	game := "/" + gamesvc.GameService_ServiceDesc.ServiceName + "/"
+	login := "/" + loginsvc.LoginService_ServiceDesc.ServiceName + "/"
+	health := "/" + healthpb.Health_ServiceDesc.ServiceName + "/"
+
+	return Options{
+		Default: Limit{Rate: 10, Burst: 20},
+		Methods: map[string]Limit{
+			game + "CreateRoom":  Every(10*time.Second, 3),
+			game + "Join":        Every(time.Second, 5),
+			login + "LoginGuest": Every(time.Minute, 5),
+			// probes come from the same address
+			health + "Check": {Rate: rate.Inf},
+			health + "Watch": {Rate: rate.Inf},
+		},
+	}
+}
+
+// Players finds players by their auth tokens.
+type Players interface {
+	GetPlayer(ctx context.Context, token string) (*gamesvc.Player, error)
+}
+
+// Limiter limits RPCs of every client. Players are told apart by their IDs
+// and other clients by IP.
+type Limiter struct {
+	log     zerolog.Logger
+	players Players
+	def     *Keyed
+	methods map[string]*Keyed
+}
+
+func New(log zerolog.Logger, players Players, opts Options) *Limiter {
+	methods := make(map[string]*Keyed, len(opts.Methods))
+	for method, limit := range opts.Methods {
+		methods[method] = NewKeyed(limit)
+	}
+
+	return &Limiter{
+		log:     log,
+		players: players,
+		def:     NewKeyed(opts.Default),
+		methods: methods,
+	}
+}
+
+// UnaryServerInterceptor rejects requests over the limit with
+// ResourceExhausted.
+func (l *Limiter) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		err := l.allow(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor limits how often streams are opened, messages of
+// streams are limited by their handlers.
+func (l *Limiter) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(
+		srv any,
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		err := l.allow(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+func (l *Limiter) allow(ctx context.Context, method string) error {
+	keyed, ok := l.methods[method]
+	if !ok {
+		keyed = l.def
+	}
+
+	key, authenticated := l.clientKey(ctx)
+	if keyed.Allow(key) {
+		return nil
+	}
+
+	log := zerolog.Ctx(ctx)
+	if log.GetLevel() == zerolog.Disabled {
+		log = &l.log
+	}
+	event := log.Warn().Str("method", method).Bool("authenticated", authenticated)
+	if p, ok := peer.FromContext(ctx); ok {
+		event = event.Stringer("peer", p.Addr)
+	}
+	event.Msg("client is rate limited")
+	metrics.RateLimited.WithLabelValues(method).Inc()
+
+	return status.Error(codes.ResourceExhausted, "too many requests, try again later")
+}
+
+// clientKey returns ID of the player, or IP of the client if the request is
+// not authenticated. Auth tokens are verified, otherwise a client would get
+// a new bucket with every random token.
+func (l *Limiter) clientKey(ctx context.Context) (key string, authenticated bool) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if tokens := md.Get(mdkey.Auth); len(tokens) > 0 && tokens[0] != "" {
+		player, err := l.players.GetPlayer(ctx, tokens[0])
+		if err == nil {
+			return "player:" + player.Id, true
+		}
+	}
+
+	return "ip:" + clientIP(ctx), false
+}
+
+// clientIP returns IP of the peer. Behind a proxy, e.g. on fly.io, the
+// server has to accept the PROXY protocol, otherwise this is IP of the
+// proxy.
+func clientIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
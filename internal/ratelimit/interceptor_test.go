@@ -0,0 +1,113 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	"github.com/knightpp/alias-proto/go/mdkey"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type fakePlayers map[string]string
+
+func (f fakePlayers) GetPlayer(_ context.Context, token string) (*gamesvc.Player, error) {
+	id, ok := f[token]
+	if !ok {
+		return nil, errors.New("player not found")
+	}
+	return &gamesvc.Player{Id: id}, nil
+}
+
+func requestContext(addr string, token string) context.Context {
+	ctx := peer.NewContext(context.Background(), &peer.Peer{
+		Addr: &net.TCPAddr{IP: net.ParseIP(addr), Port: 4242},
+	})
+	if token != "" {
+		ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(mdkey.Auth, token))
+	}
+	return ctx
+}
+
+func TestClientKey(t *testing.T) {
+	l := New(zerolog.Nop(), fakePlayers{"valid-token": "player-id"}, Options{})
+
+	tests := []struct {
+		name          string
+		ctx           context.Context
+		key           string
+		authenticated bool
+	}{
+		{
+			name:          "verified token",
+			ctx:           requestContext("10.0.0.1", "valid-token"),
+			key:           "player:player-id",
+			authenticated: true,
+		},
+		{
+			name: "unknown token",
+			ctx:  requestContext("10.0.0.1", "random-token"),
+			key:  "ip:10.0.0.1",
+		},
+		{
+			name: "no token",
+			ctx:  requestContext("10.0.0.2", ""),
+			key:  "ip:10.0.0.2",
+		},
+		{
+			name: "ipv6",
+			ctx:  requestContext("2001:db8::1", ""),
+			key:  "ip:2001:db8::1",
+		},
+		{
+			name: "no peer",
+			ctx:  context.Background(),
+			key:  "ip:",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, authenticated := l.clientKey(tt.ctx)
+			assert.Equal(t, tt.key, key)
+			assert.Equal(t, tt.authenticated, authenticated)
+		})
+	}
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	l := New(zerolog.Nop(), fakePlayers{"token-a": "a"}, Options{
+		Default: Every(time.Hour, 1),
+		Methods: map[string]Limit{
+			"/svc/Strict": Every(time.Hour, 0),
+		},
+	})
+	intercept := l.UnaryServerInterceptor()
+	handler := func(context.Context, any) (any, error) {
+		return "ok", nil
+	}
+	call := func(ctx context.Context, method string) error {
+		_, err := intercept(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		return err
+	}
+
+	// random tokens share the bucket of the IP
+	require.NoError(t, call(requestContext("10.0.0.1", "random-1"), "/svc/Method"))
+	err := call(requestContext("10.0.0.1", "random-2"), "/svc/Method")
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// the player has their own bucket
+	require.NoError(t, call(requestContext("10.0.0.1", "token-a"), "/svc/Method"))
+
+	err = call(requestContext("10.0.0.3", ""), "/svc/Strict")
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
@@ -0,0 +1,94 @@
+// Package ratelimit limits how often clients can call RPCs.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Limit is a token bucket that fills at Rate tokens per second up to Burst.
+type Limit struct {
+	Rate  rate.Limit
+	Burst int
+}
+
+// Every returns a limit of burst events that refills one event per
+// interval.
+func Every(interval time.Duration, burst int) Limit {
+	return Limit{Rate: rate.Every(interval), Burst: burst}
+}
+
+func (l Limit) Limiter() *rate.Limiter {
+	return rate.NewLimiter(l.Rate, l.Burst)
+}
+
+// full returns time it takes to fill an empty bucket.
+func (l Limit) full() time.Duration {
+	if l.Rate == rate.Inf || l.Rate <= 0 {
+		return 0
+	}
+	return time.Duration(float64(l.Burst) / float64(l.Rate) * float64(time.Second))
+}
+
+// Keyed keeps a limiter for every key, e.g. a player or an IP. Limiters
+// with full buckets are forgotten, since they are the same as new ones.
+type Keyed struct {
+	limit Limit
+
+	mu        sync.Mutex
+	limiters  map[string]*keyedLimiter
+	lastSweep time.Time
+}
+
+type keyedLimiter struct {
+	*rate.Limiter
+	lastUsed time.Time
+}
+
+func NewKeyed(limit Limit) *Keyed {
+	return &Keyed{
+		limit:     limit,
+		limiters:  make(map[string]*keyedLimiter),
+		lastSweep: time.Now(),
+	}
+}
+
+// Allow reports whether an event of the key may happen now.
+func (k *Keyed) Allow(key string) bool {
+	if k.limit.Rate == rate.Inf {
+		return true
+	}
+
+	now := time.Now()
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.sweep(now)
+
+	l, ok := k.limiters[key]
+	if !ok {
+		l = &keyedLimiter{Limiter: k.limit.Limiter()}
+		k.limiters[key] = l
+	}
+	l.lastUsed = now
+
+	return l.AllowN(now, 1)
+}
+
+// sweep deletes limiters that filled up, at most once in the fill time.
+func (k *Keyed) sweep(now time.Time) {
+	full := k.limit.full()
+	if full <= 0 || now.Sub(k.lastSweep) < full {
+		return
+	}
+	k.lastSweep = now
+
+	for key, l := range k.limiters {
+		if now.Sub(l.lastUsed) >= full {
+			delete(k.limiters, key)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+)
+
+func TestKeyedAllow(t *testing.T) {
+	tests := []struct {
+		name    string
+		limit   Limit
+		events  int
+		allowed int
+	}{
+		{
+			name:    "burst",
+			limit:   Every(time.Hour, 3),
+			events:  5,
+			allowed: 3,
+		},
+		{
+			name:    "unlimited",
+			limit:   Limit{Rate: rate.Inf},
+			events:  100,
+			allowed: 100,
+		},
+		{
+			name:    "zero burst",
+			limit:   Every(time.Hour, 0),
+			events:  3,
+			allowed: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := NewKeyed(tt.limit)
+
+			var allowed int
+			for i := 0; i < tt.events; i++ {
+				if k.Allow("key") {
+					allowed++
+				}
+			}
+			assert.Equal(t, tt.allowed, allowed)
+		})
+	}
+}
+
+func TestKeyedSeparatesKeys(t *testing.T) {
+	k := NewKeyed(Every(time.Hour, 1))
+
+	assert.True(t, k.Allow("a"))
+	assert.False(t, k.Allow("a"))
+	assert.True(t, k.Allow("b"))
+}
+
+func TestKeyedForgetsFullBuckets(t *testing.T) {
+	k := NewKeyed(Every(time.Millisecond, 1))
+	k.Allow("a")
+
+	start := time.Now()
+	k.sweep(start.Add(time.Second))
+	assert.Empty(t, k.limiters)
+}
+
+func TestLimitFull(t *testing.T) {
+	assert.Equal(t, 3*time.Minute, Every(time.Minute, 3).full())
+	assert.Zero(t, Limit{Rate: rate.Inf, Burst: 3}.full())
+	assert.Zero(t, Limit{Rate: 0, Burst: 3}.full())
+}
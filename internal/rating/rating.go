@@ -0,0 +1,72 @@
+// Package rating implements Elo rating for matches with two or more teams.
+package rating
+
+import "math"
+
+const (
+	// Initial is a rating of a player who has never played.
+	Initial = 1500.0
+	// K is the maximum rating change in a single match.
+	K = 32.0
+)
+
+type Team struct {
+	// Rating is an average rating of team players.
+	Rating float64
+	// Score is how many points team got in the match.
+	Score float64
+}
+
+// Deltas returns rating change of each team. Every team is compared with
+// every other team and the result is scaled so that a match with many teams
+// is worth as much as a match with two.
+func Deltas(teams []Team) []float64 {
+	deltas := make([]float64, len(teams))
+	if len(teams) < 2 {
+		return deltas
+	}
+
+	for i, a := range teams {
+		for j, b := range teams {
+			if i == j {
+				continue
+			}
+
+			deltas[i] += K * (actual(a.Score, b.Score) - Expected(a.Rating, b.Rating))
+		}
+
+		deltas[i] /= float64(len(teams) - 1)
+	}
+
+	return deltas
+}
+
+// Expected returns probability of a team with rating a beating a team with
+// rating b.
+func Expected(a, b float64) float64 {
+	return 1 / (1 + math.Pow(10, (b-a)/400))
+}
+
+func Average(ratings ...float64) float64 {
+	if len(ratings) == 0 {
+		return Initial
+	}
+
+	var sum float64
+	for _, r := range ratings {
+		sum += r
+	}
+
+	return sum / float64(len(ratings))
+}
+
+func actual(a, b float64) float64 {
+	switch {
+	case a > b:
+		return 1
+	case a < b:
+		return 0
+	default:
+		return 0.5
+	}
+}
@@ -0,0 +1,103 @@
+package rating_test
+
+import (
+	"testing"
+
+	"github.com/knightpp/alias-server/internal/rating"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpected(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b float64
+		want float64
+	}{
+		{name: "equal", a: 1500, b: 1500, want: 0.5},
+		{name: "400 points stronger", a: 1900, b: 1500, want: 10.0 / 11},
+		{name: "400 points weaker", a: 1500, b: 1900, want: 1.0 / 11},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, rating.Expected(tt.a, tt.b), 1e-9)
+		})
+	}
+}
+
+func TestDeltas(t *testing.T) {
+	tests := []struct {
+		name  string
+		teams []rating.Team
+		want  []float64
+	}{
+		{
+			name:  "no teams",
+			teams: nil,
+			want:  []float64{},
+		},
+		{
+			name:  "single team",
+			teams: []rating.Team{{Rating: 1500, Score: 10}},
+			want:  []float64{0},
+		},
+		{
+			name: "equal teams, win",
+			teams: []rating.Team{
+				{Rating: 1500, Score: 10},
+				{Rating: 1500, Score: 5},
+			},
+			want: []float64{rating.K / 2, -rating.K / 2},
+		},
+		{
+			name: "equal teams, draw",
+			teams: []rating.Team{
+				{Rating: 1500, Score: 5},
+				{Rating: 1500, Score: 5},
+			},
+			want: []float64{0, 0},
+		},
+		{
+			name: "favourite wins",
+			teams: []rating.Team{
+				{Rating: 1900, Score: 10},
+				{Rating: 1500, Score: 5},
+			},
+			want: []float64{rating.K / 11, -rating.K / 11},
+		},
+		{
+			name: "underdog wins",
+			teams: []rating.Team{
+				{Rating: 1500, Score: 10},
+				{Rating: 1900, Score: 5},
+			},
+			want: []float64{rating.K * 10 / 11, -rating.K * 10 / 11},
+		},
+		{
+			name: "three equal teams",
+			teams: []rating.Team{
+				{Rating: 1500, Score: 10},
+				{Rating: 1500, Score: 5},
+				{Rating: 1500, Score: 0},
+			},
+			want: []float64{rating.K / 2, 0, -rating.K / 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rating.Deltas(tt.teams)
+			assert.InDeltaSlice(t, tt.want, got, 1e-9)
+
+			var sum float64
+			for _, d := range got {
+				sum += d
+			}
+			assert.InDelta(t, 0, sum, 1e-9, "ratings are zero-sum")
+		})
+	}
+}
+
+func TestAverage(t *testing.T) {
+	assert.Equal(t, rating.Initial, rating.Average())
+	assert.Equal(t, 1500.0, rating.Average(1400, 1600))
+	assert.Equal(t, 0.0, rating.Average(0))
+}
@@ -0,0 +1,299 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	"github.com/knightpp/alias-server/internal/game"
+	"github.com/knightpp/alias-server/internal/storage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func (gs *GameService) SendFriendRequest(
+	ctx context.Context,
+	req *gamesvc.SendFriendRequestRequest,
+) (*gamesvc.SendFriendRequestResponse, error) {
+	player, err := gs.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	friend, err := gs.otherPlayer(ctx, player, req.PlayerId)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := gs.db.GetFriendList(ctx, player.Id)
+	if err != nil {
+		return nil, fmt.Errorf("get friend list: %w", err)
+	}
+
+	// both players want to be friends, no need to wait for another accept
+	if list.HasIncoming(friend.Id) {
+		err = gs.acceptFriendRequest(ctx, player, friend.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		return &gamesvc.SendFriendRequestResponse{}, nil
+	}
+
+	err = gs.db.AddFriendRequest(ctx, player.Id, friend.Id)
+	if err != nil {
+		if errors.Is(err, storage.ErrAlreadyFriends) {
+			return nil, status.Error(codes.AlreadyExists, err.Error())
+		}
+
+		return nil, fmt.Errorf("add friend request: %w", err)
+	}
+
+	gs.notifications.Publish(friend.Id, &gamesvc.Notification{
+		Notification: &gamesvc.Notification_FriendRequest{
+			FriendRequest: &gamesvc.FriendRequestReceived{
+				From: player,
+			},
+		},
+	})
+
+	return &gamesvc.SendFriendRequestResponse{}, nil
+}
+
+func (gs *GameService) AcceptFriendRequest(
+	ctx context.Context,
+	req *gamesvc.AcceptFriendRequestRequest,
+) (*gamesvc.AcceptFriendRequestResponse, error) {
+	player, err := gs.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = gs.acceptFriendRequest(ctx, player, req.PlayerId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gamesvc.AcceptFriendRequestResponse{}, nil
+}
+
+// RemoveFriend removes a friend or declines a friend request.
+func (gs *GameService) RemoveFriend(
+	ctx context.Context,
+	req *gamesvc.RemoveFriendRequest,
+) (*gamesvc.RemoveFriendResponse, error) {
+	player, err := gs.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.PlayerId == "" {
+		return nil, status.Error(codes.InvalidArgument, "player id is empty")
+	}
+
+	err = gs.db.RemoveFriend(ctx, player.Id, req.PlayerId)
+	if err != nil {
+		return nil, fmt.Errorf("remove friend: %w", err)
+	}
+
+	return &gamesvc.RemoveFriendResponse{}, nil
+}
+
+func (gs *GameService) ListFriends(
+	ctx context.Context,
+	_ *gamesvc.ListFriendsRequest,
+) (*gamesvc.ListFriendsResponse, error) {
+	player, err := gs.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := gs.db.GetFriendList(ctx, player.Id)
+	if err != nil {
+		return nil, fmt.Errorf("get friend list: %w", err)
+	}
+
+	friends, err := gs.getPlayers(ctx, list.Friends)
+	if err != nil {
+		return nil, err
+	}
+
+	incoming, err := gs.getPlayers(ctx, list.Incoming)
+	if err != nil {
+		return nil, err
+	}
+
+	outgoing, err := gs.getPlayers(ctx, list.Outgoing)
+	if err != nil {
+		return nil, err
+	}
+
+	playerRooms := gs.game.FindPlayers(list.Friends)
+
+	resp := &gamesvc.ListFriendsResponse{
+		Friends:  make([]*gamesvc.Friend, len(friends)),
+		Incoming: incoming,
+		Outgoing: outgoing,
+	}
+	for i, friend := range friends {
+		roomID, online := playerRooms[friend.Id]
+		resp.Friends[i] = &gamesvc.Friend{
+			Player: friend,
+			Online: online,
+			RoomId: roomID,
+		}
+	}
+
+	return resp, nil
+}
+
+// InviteToRoom sends an invite to the room the player is in. The invite has
+// a password of the room, so only friends can be invited.
+func (gs *GameService) InviteToRoom(
+	ctx context.Context,
+	req *gamesvc.InviteToRoomRequest,
+) (*gamesvc.InviteToRoomResponse, error) {
+	player, err := gs.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	friend, err := gs.otherPlayer(ctx, player, req.PlayerId)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := gs.db.GetFriendList(ctx, player.Id)
+	if err != nil {
+		return nil, fmt.Errorf("get friend list: %w", err)
+	}
+
+	if !list.IsFriend(friend.Id) {
+		return nil, status.Error(codes.PermissionDenied, "player is not a friend")
+	}
+
+	room, password, err := gs.game.RoomInvite(req.RoomId, player.Id)
+	switch {
+	case errors.Is(err, game.ErrRoomNotFound):
+		return nil, status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, game.ErrPlayerNotInRoom):
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	case err != nil:
+		return nil, fmt.Errorf("room invite: %w", err)
+	}
+
+	delivered := gs.notifications.Publish(friend.Id, &gamesvc.Notification{
+		Notification: &gamesvc.Notification_RoomInvite{
+			RoomInvite: &gamesvc.RoomInvite{
+				From:     player,
+				RoomId:   room.Id,
+				RoomName: room.Name,
+				Password: password,
+			},
+		},
+	})
+	if !delivered {
+		return nil, status.Error(codes.FailedPrecondition, "player is offline")
+	}
+
+	return &gamesvc.InviteToRoomResponse{}, nil
+}
+
+// Notifications streams friend, party and room notifications to the player
+// until the stream is closed.
+func (gs *GameService) Notifications(
+	_ *gamesvc.NotificationsRequest,
+	stream gamesvc.GameService_NotificationsServer,
+) error {
+	ctx := stream.Context()
+
+	player, err := gs.authenticate(ctx)
+	if err != nil {
+		return err
+	}
+
+	notifications, unsubscribe := gs.notifications.Subscribe(player.Id)
+	defer unsubscribe()
+
+	// headers tell the client that nothing sent from now on is missed
+	err = stream.SendHeader(metadata.MD{})
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+
+		case n := <-notifications:
+			err := stream.Send(n)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (gs *GameService) acceptFriendRequest(ctx context.Context, player *gamesvc.Player, fromID string) error {
+	err := gs.db.AcceptFriendRequest(ctx, player.Id, fromID)
+	if err != nil {
+		if errors.Is(err, storage.ErrFriendRequestNotFound) {
+			return status.Error(codes.NotFound, err.Error())
+		}
+
+		return fmt.Errorf("accept friend request: %w", err)
+	}
+
+	gs.notifications.Publish(fromID, &gamesvc.Notification{
+		Notification: &gamesvc.Notification_FriendAccepted{
+			FriendAccepted: &gamesvc.FriendRequestAccepted{
+				By: player,
+			},
+		},
+	})
+
+	return nil
+}
+
+// otherPlayer returns a player with the id who is not the player.
+func (gs *GameService) otherPlayer(ctx context.Context, player *gamesvc.Player, id string) (*gamesvc.Player, error) {
+	switch id {
+	case "":
+		return nil, status.Error(codes.InvalidArgument, "player id is empty")
+	case player.Id:
+		return nil, status.Error(codes.InvalidArgument, "player cannot befriend themselves")
+	}
+
+	other, err := gs.db.GetPlayerByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+
+		return nil, fmt.Errorf("get player: %w", err)
+	}
+
+	return other, nil
+}
+
+// getPlayers returns profiles of players. Players without a profile are
+// returned with only ID set.
+func (gs *GameService) getPlayers(ctx context.Context, ids []string) ([]*gamesvc.Player, error) {
+	players := make([]*gamesvc.Player, len(ids))
+	for i, id := range ids {
+		player, err := gs.db.GetPlayerByID(ctx, id)
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			player = &gamesvc.Player{Id: id}
+		case err != nil:
+			return nil, fmt.Errorf("get player: %w", err)
+		}
+
+		players[i] = player
+	}
+
+	return players, nil
+}
@@ -0,0 +1,188 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	"github.com/knightpp/alias-server/internal/game"
+	"github.com/knightpp/alias-server/internal/party"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// InviteToParty invites a friend to the party of the player.
+func (gs *GameService) InviteToParty(
+	ctx context.Context,
+	req *gamesvc.InviteToPartyRequest,
+) (*gamesvc.InviteToPartyResponse, error) {
+	player, err := gs.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	friend, err := gs.otherPlayer(ctx, player, req.PlayerId)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := gs.db.GetFriendList(ctx, player.Id)
+	if err != nil {
+		return nil, fmt.Errorf("get friend list: %w", err)
+	}
+
+	if !list.IsFriend(friend.Id) {
+		return nil, status.Error(codes.PermissionDenied, "player is not a friend")
+	}
+
+	p, err := gs.parties.Invite(player, friend.Id)
+	if err != nil {
+		return nil, partyError(err)
+	}
+
+	gs.notifications.Publish(friend.Id, &gamesvc.Notification{
+		Notification: &gamesvc.Notification_PartyInvite{
+			PartyInvite: &gamesvc.PartyInvite{
+				From:  player,
+				Party: p,
+			},
+		},
+	})
+
+	return &gamesvc.InviteToPartyResponse{
+		Party: p,
+	}, nil
+}
+
+func (gs *GameService) JoinParty(
+	ctx context.Context,
+	req *gamesvc.JoinPartyRequest,
+) (*gamesvc.JoinPartyResponse, error) {
+	player, err := gs.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := gs.parties.Join(player, req.PartyId)
+	if err != nil {
+		return nil, partyError(err)
+	}
+
+	gs.announceParty(p, player.Id)
+
+	return &gamesvc.JoinPartyResponse{
+		Party: p,
+	}, nil
+}
+
+func (gs *GameService) LeaveParty(
+	ctx context.Context,
+	_ *gamesvc.LeavePartyRequest,
+) (*gamesvc.LeavePartyResponse, error) {
+	player, err := gs.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := gs.parties.Leave(player.Id)
+	if err != nil {
+		return nil, partyError(err)
+	}
+
+	gs.announceParty(p, player.Id)
+
+	return &gamesvc.LeavePartyResponse{}, nil
+}
+
+func (gs *GameService) GetParty(
+	ctx context.Context,
+	_ *gamesvc.GetPartyRequest,
+) (*gamesvc.GetPartyResponse, error) {
+	player, err := gs.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p, ok := gs.parties.Get(player.Id)
+	if !ok {
+		return nil, status.Error(codes.NotFound, party.ErrNotFound.Error())
+	}
+
+	return &gamesvc.GetPartyResponse{
+		Party: p,
+	}, nil
+}
+
+// followLeader reserves a team in the room for the party of the leader and
+// tells the party to join the room. The leader joins alone if no team has
+// space for the party.
+func (gs *GameService) followLeader(leaderID, roomID string) error {
+	followers := gs.parties.Followers(leaderID)
+	if len(followers) == 0 {
+		return nil
+	}
+
+	ids := []string{leaderID}
+	for _, p := range followers {
+		ids = append(ids, p.Id)
+	}
+
+	password, err := gs.game.ReserveTeam(roomID, ids)
+	switch {
+	case errors.Is(err, game.ErrNoTeamSpace):
+		gs.log.Info().Str("player-id", leaderID).Str("room-id", roomID).Msg("party doesn't fit in the room")
+		return nil
+	case err != nil:
+		return err
+	}
+
+	gs.notifyFollowers(followers, roomID, password)
+	return nil
+}
+
+func (gs *GameService) notifyFollowers(followers []*gamesvc.Player, roomID string, password *string) {
+	for _, p := range followers {
+		gs.notifications.Publish(p.Id, &gamesvc.Notification{
+			Notification: &gamesvc.Notification_PartyFollow{
+				PartyFollow: &gamesvc.PartyFollow{
+					RoomId:   roomID,
+					Password: password,
+				},
+			},
+		})
+	}
+}
+
+// announceParty notifies members of the party except the player about
+// a change.
+func (gs *GameService) announceParty(p *gamesvc.Party, playerID string) {
+	for _, member := range p.Members {
+		if member.Id == playerID {
+			continue
+		}
+
+		gs.notifications.Publish(member.Id, &gamesvc.Notification{
+			Notification: &gamesvc.Notification_PartyUpdate{
+				PartyUpdate: &gamesvc.PartyUpdate{
+					Party: p,
+				},
+			},
+		})
+	}
+}
+
+func partyError(err error) error {
+	switch {
+	case errors.Is(err, party.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, party.ErrFull):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, party.ErrInParty):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, party.ErrNotLeader), errors.Is(err, party.ErrNotInvited):
+		return status.Error(codes.PermissionDenied, err.Error())
+	default:
+		return err
+	}
+}
@@ -4,10 +4,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
+	"sync"
 
 	gamesvc "github.com/knightpp/alias-proto/go/game_service"
 	"github.com/knightpp/alias-proto/go/mdkey"
 	"github.com/knightpp/alias-server/internal/game"
+	"github.com/knightpp/alias-server/internal/matchmaking"
+	"github.com/knightpp/alias-server/internal/notification"
+	"github.com/knightpp/alias-server/internal/party"
 	"github.com/knightpp/alias-server/internal/storage"
 	"github.com/rs/zerolog"
 	"google.golang.org/grpc/codes"
@@ -17,48 +22,127 @@ import (
 
 var _ gamesvc.GameServiceServer = (*GameService)(nil)
 
+const (
+	defaultHistoryLimit = 20
+	maxHistoryLimit     = storage.MaxPlayerMatches
+
+	defaultRoomsPageSize = 50
+	maxRoomsPageSize     = 100
+
+	defaultLeaderboardPageSize = 20
+	maxLeaderboardPageSize     = 100
+
+	defaultQuickMatchTeams = 2
+)
+
 type GameService struct {
 	gamesvc.UnimplementedGameServiceServer
 
 	log zerolog.Logger
-	db  storage.Player
+	db  storage.Storage
+
+	game          *game.Game
+	matchmaker    *matchmaking.Queue
+	notifications *notification.Hub
+	parties       *party.Manager
 
-	game *game.Game
+	webStreamsMu sync.Mutex
+	webStreams   map[webStreamKey]*webStream
 }
 
-func New(log zerolog.Logger, db storage.Player) *GameService {
+type Options struct {
+	Game game.Options
+}
+
+func DefaultOptions() Options {
+	return Options{
+		Game: game.DefaultOptions(),
+	}
+}
+
+func New(log zerolog.Logger, db storage.Storage, opts Options) *GameService {
+	g := game.New(log, db, opts.Game)
+	matchmaker := matchmaking.New(log, db, g, matchmaking.DefaultOptions())
+
 	return &GameService{
-		game: game.New(log),
-		log:  log,
-		db:   db,
+		game:          g,
+		matchmaker:    matchmaker,
+		notifications: notification.NewHub(log),
+		parties:       party.NewManager(),
+		webStreams:    make(map[webStreamKey]*webStream),
+		log:           log,
+		db:            db,
 	}
 }
 
-func (gs *GameService) ListRooms(_ context.Context, _ *gamesvc.ListRoomsRequest) (*gamesvc.ListRoomsResponse, error) {
-	return &gamesvc.ListRoomsResponse{
-		Rooms: gs.game.ListRooms(),
-	}, nil
+// Start runs background work of the service, i.e. matchmaking and closing
+// of expired rooms, until ctx is done.
+func (gs *GameService) Start(ctx context.Context) {
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		gs.matchmaker.Start(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		gs.game.StartJanitor(ctx)
+	}()
+	wg.Wait()
 }
 
-func (gs *GameService) CreateRoom(ctx context.Context, req *gamesvc.CreateRoomRequest) (*gamesvc.CreateRoomResponse, error) {
-	md, ok := metadata.FromIncomingContext(ctx)
-	if !ok {
-		return nil, errors.New("no metadata in request")
+// Game returns the game that runs rooms of the service.
+func (gs *GameService) Game() *game.Game {
+	return gs.game
+}
+
+func (gs *GameService) ListRooms(_ context.Context, req *gamesvc.ListRoomsRequest) (*gamesvc.ListRoomsResponse, error) {
+	pageSize := int(req.PageSize)
+	switch {
+	case pageSize == 0:
+		pageSize = defaultRoomsPageSize
+	case pageSize > maxRoomsPageSize:
+		pageSize = maxRoomsPageSize
+	}
+
+	offset, err := parsePageToken(req.PageToken)
+	if err != nil {
+		return nil, err
 	}
 
-	tokenMd := md.Get("token")
-	if len(tokenMd) != 1 {
-		return nil, fmt.Errorf("unexpected metadata value: %#v", tokenMd)
+	// one more room tells whether there is a next page
+	rooms := gs.game.ListRooms(game.RoomQuery{
+		Language: req.Langugage,
+		Statuses: req.Statuses,
+		Offset:   offset,
+		Limit:    pageSize + 1,
+	})
+
+	var nextPageToken string
+	if len(rooms) > pageSize {
+		rooms = rooms[:pageSize]
+		nextPageToken = strconv.Itoa(offset + pageSize)
 	}
 
-	token := tokenMd[0]
+	return &gamesvc.ListRoomsResponse{
+		Rooms:         rooms,
+		NextPageToken: nextPageToken,
+	}, nil
+}
 
-	player, err := gs.db.GetPlayer(ctx, token)
+func (gs *GameService) CreateRoom(ctx context.Context, req *gamesvc.CreateRoomRequest) (*gamesvc.CreateRoomResponse, error) {
+	player, err := gs.authenticate(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("get player: %w", err)
+		return nil, err
 	}
 
-	id := gs.game.CreateRoom(player, req)
+	id, err := gs.game.CreateRoom(player, req)
+	switch {
+	case errors.Is(err, game.ErrTooManyRooms), errors.Is(err, game.ErrTooManyPlayerRooms):
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	case err != nil:
+		return nil, fmt.Errorf("create room: %w", err)
+	}
 
 	return &gamesvc.CreateRoomResponse{
 		Id: id,
@@ -75,17 +159,202 @@ func (gs *GameService) Join(stream gamesvc.GameService_JoinServer) error {
 		return status.Errorf(codes.InvalidArgument, "get room id from md: %s", err)
 	}
 
+	player, err := gs.authenticate(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = gs.followLeader(player.Id, roomID)
+	if err != nil {
+		return err
+	}
+
+	return gs.game.StartPlayerInRoom(roomID, player, stream)
+}
+
+func (gs *GameService) QuickMatch(req *gamesvc.QuickMatchRequest, stream gamesvc.GameService_QuickMatchServer) error {
+	ctx := stream.Context()
+
+	player, err := gs.authenticate(ctx)
+	if err != nil {
+		return err
+	}
+
+	teams := int(req.Teams)
+	if teams == 0 {
+		teams = defaultQuickMatchTeams
+	}
+	if teams < matchmaking.MinTeams || teams > matchmaking.MaxTeams {
+		return status.Errorf(
+			codes.InvalidArgument,
+			"number of teams should be from %d to %d",
+			matchmaking.MinTeams, matchmaking.MaxTeams,
+		)
+	}
+
+	// a party queues as a group on behalf of its leader
+	players := []*gamesvc.Player{player}
+	if p, ok := gs.parties.Get(player.Id); ok {
+		if p.LeaderId != player.Id {
+			return status.Error(codes.FailedPrecondition, "only party leader can queue")
+		}
+
+		players = p.Members
+	}
+
+	err = stream.Send(&gamesvc.QuickMatchUpdate{
+		Update: &gamesvc.QuickMatchUpdate_Queued{
+			Queued: &gamesvc.QuickMatchQueued{},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	roomID, err := gs.matchmaker.Enqueue(ctx, matchmaking.Request{
+		Players:  players,
+		Language: req.Langugage,
+		Teams:    teams,
+	})
+	switch {
+	case errors.Is(err, matchmaking.ErrAlreadyQueued):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, matchmaking.ErrTimeout):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case err != nil:
+		return status.FromContextError(err).Err()
+	}
+
+	gs.notifyFollowers(gs.parties.Followers(player.Id), roomID, nil)
+
+	return stream.Send(&gamesvc.QuickMatchUpdate{
+		Update: &gamesvc.QuickMatchUpdate_Found{
+			Found: &gamesvc.QuickMatchFound{
+				RoomId: roomID,
+			},
+		},
+	})
+}
+
+func (gs *GameService) GetPlayerHistory(
+	ctx context.Context,
+	req *gamesvc.GetPlayerHistoryRequest,
+) (*gamesvc.GetPlayerHistoryResponse, error) {
+	if req.PlayerId == "" {
+		return nil, status.Error(codes.InvalidArgument, "player id is empty")
+	}
+
+	limit := int(req.Limit)
+	switch {
+	case limit == 0:
+		limit = defaultHistoryLimit
+	case limit > maxHistoryLimit:
+		limit = maxHistoryLimit
+	}
+
+	stats, err := gs.db.GetPlayerStats(ctx, req.PlayerId)
+	if err != nil {
+		return nil, fmt.Errorf("get player stats: %w", err)
+	}
+
+	matches, err := gs.db.GetPlayerMatches(ctx, req.PlayerId, limit)
+	if err != nil {
+		return nil, fmt.Errorf("get player matches: %w", err)
+	}
+
+	return &gamesvc.GetPlayerHistoryResponse{
+		Stats:   stats,
+		Matches: matches,
+	}, nil
+}
+
+func (gs *GameService) GetMatch(ctx context.Context, req *gamesvc.GetMatchRequest) (*gamesvc.GetMatchResponse, error) {
+	match, err := gs.db.GetMatch(ctx, req.Id)
+	if err != nil {
+		if errors.Is(err, storage.ErrMatchNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+
+		return nil, fmt.Errorf("get match: %w", err)
+	}
+
+	return &gamesvc.GetMatchResponse{
+		Match: match,
+	}, nil
+}
+
+func (gs *GameService) GetLeaderboard(
+	ctx context.Context,
+	req *gamesvc.GetLeaderboardRequest,
+) (*gamesvc.GetLeaderboardResponse, error) {
+	pageSize := int(req.PageSize)
+	switch {
+	case pageSize == 0:
+		pageSize = defaultLeaderboardPageSize
+	case pageSize > maxLeaderboardPageSize:
+		pageSize = maxLeaderboardPageSize
+	}
+
+	offset, err := parsePageToken(req.PageToken)
+	if err != nil {
+		return nil, err
+	}
+
+	// one more entry tells whether there is a next page
+	entries, err := gs.db.GetLeaderboard(ctx, storage.LeaderboardQuery{
+		Kind:     req.Kind,
+		Language: req.Langugage,
+		Window:   req.Window,
+		Offset:   offset,
+		Limit:    pageSize + 1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get leaderboard: %w", err)
+	}
+
+	var nextPageToken string
+	if len(entries) > pageSize {
+		entries = entries[:pageSize]
+		nextPageToken = strconv.Itoa(offset + pageSize)
+	}
+
+	return &gamesvc.GetLeaderboardResponse{
+		Entries:       entries,
+		NextPageToken: nextPageToken,
+	}, nil
+}
+
+// parsePageToken returns the offset encoded in the page token. An empty token
+// is the first page.
+func parsePageToken(token string) (int, error) {
+	if token == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.ParseUint(token, 10, 31)
+	if err != nil {
+		return 0, status.Errorf(codes.InvalidArgument, "invalid page token: %s", err)
+	}
+
+	return int(n), nil
+}
+
+// authenticate returns the player identified by auth token in the request
+// metadata.
+func (gs *GameService) authenticate(ctx context.Context) (*gamesvc.Player, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
 	authToken, err := singleFieldMD(mdkey.Auth, md)
 	if err != nil {
-		return status.Errorf(codes.Unauthenticated, "get auth token from md: %s", err)
+		return nil, status.Errorf(codes.Unauthenticated, "get auth token from md: %s", err)
 	}
 
 	player, err := gs.db.GetPlayer(ctx, authToken)
 	if err != nil {
-		return status.Errorf(codes.Unauthenticated, "get player: %s", err)
+		return nil, status.Errorf(codes.Unauthenticated, "get player: %s", err)
 	}
 
-	return gs.game.StartPlayerInRoom(roomID, player, stream)
+	return player, nil
 }
 
 func singleFieldMD(field string, md metadata.MD) (string, error) {
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"context"
+	"io"
+
+	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// webStream is the Join stream of a player who receives messages by
+// JoinStream and sends them by SendMessage.
+type webStream struct {
+	gamesvc.GameService_JoinStreamServer
+
+	msgs chan *gamesvc.Message
+}
+
+type webStreamKey struct {
+	playerID, roomID string
+}
+
+func (s *webStream) Recv() (*gamesvc.Message, error) {
+	select {
+	case msg := <-s.msgs:
+		return msg, nil
+	case <-s.Context().Done():
+		return nil, io.EOF
+	}
+}
+
+// JoinStream is Join for clients that can't stream messages to the server,
+// they send them by SendMessage.
+func (gs *GameService) JoinStream(req *gamesvc.JoinStreamRequest, stream gamesvc.GameService_JoinStreamServer) error {
+	player, err := gs.authenticate(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	err = gs.followLeader(player.Id, req.RoomId)
+	if err != nil {
+		return err
+	}
+
+	key := webStreamKey{playerID: player.Id, roomID: req.RoomId}
+	web := &webStream{
+		GameService_JoinStreamServer: stream,
+		msgs:                         make(chan *gamesvc.Message),
+	}
+
+	gs.webStreamsMu.Lock()
+	if _, ok := gs.webStreams[key]; ok {
+		gs.webStreamsMu.Unlock()
+		return status.Error(codes.AlreadyExists, "player already joined the room")
+	}
+	gs.webStreams[key] = web
+	gs.webStreamsMu.Unlock()
+
+	defer func() {
+		gs.webStreamsMu.Lock()
+		delete(gs.webStreams, key)
+		gs.webStreamsMu.Unlock()
+	}()
+
+	return gs.game.StartPlayerInRoom(req.RoomId, player, web)
+}
+
+// SendMessage sends a message to the room the player joined by JoinStream.
+func (gs *GameService) SendMessage(
+	ctx context.Context,
+	req *gamesvc.SendMessageRequest,
+) (*gamesvc.SendMessageResponse, error) {
+	player, err := gs.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Message == nil {
+		return nil, status.Error(codes.InvalidArgument, "message is required")
+	}
+
+	gs.webStreamsMu.Lock()
+	web, ok := gs.webStreams[webStreamKey{playerID: player.Id, roomID: req.RoomId}]
+	gs.webStreamsMu.Unlock()
+	if !ok {
+		return nil, status.Error(codes.FailedPrecondition, "player didn't join the room by JoinStream")
+	}
+
+	select {
+	case web.msgs <- req.Message:
+		return &gamesvc.SendMessageResponse{}, nil
+	case <-web.Context().Done():
+		return nil, status.Error(codes.FailedPrecondition, "player left the room")
+	case <-ctx.Done():
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+}
@@ -0,0 +1,30 @@
+package storage
+
+// FriendList holds IDs of friends of a player and of players with pending
+// friend requests.
+type FriendList struct {
+	Friends []string
+	// Incoming are players who sent a request to the player.
+	Incoming []string
+	// Outgoing are players the player sent a request to.
+	Outgoing []string
+}
+
+// IsFriend reports whether the player with id is a friend.
+func (l *FriendList) IsFriend(id string) bool {
+	return contains(l.Friends, id)
+}
+
+// HasIncoming reports whether the player with id sent a friend request.
+func (l *FriendList) HasIncoming(id string) bool {
+	return contains(l.Incoming, id)
+}
+
+func contains(ids []string, id string) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
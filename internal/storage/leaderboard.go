@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	"github.com/knightpp/alias-server/internal/rating"
+)
+
+// AllLanguages is a leaderboard scope that includes matches in any language.
+const AllLanguages = "*"
+
+// WeeklyLeaderboardTTL is how long a weekly leaderboard is kept after the
+// last update.
+const WeeklyLeaderboardTTL = 5 * 7 * 24 * time.Hour
+
+type LeaderboardQuery struct {
+	Kind     gamesvc.LeaderboardKind
+	Language string
+	Window   gamesvc.LeaderboardWindow
+	Offset   int
+	Limit    int
+}
+
+// Key returns a key of the leaderboard that the query reads at the moment.
+func (q LeaderboardQuery) Key(now time.Time) string {
+	language := q.Language
+	if language == "" {
+		language = AllLanguages
+	}
+
+	period := allTimePeriod
+	if q.Window == gamesvc.LeaderboardWindow_LEADERBOARD_WINDOW_WEEKLY {
+		period = WeekOf(now)
+	}
+
+	return LeaderboardKey(q.Kind, language, period)
+}
+
+const allTimePeriod = "all"
+
+func LeaderboardKey(kind gamesvc.LeaderboardKind, language, period string) string {
+	kindName := "wins"
+	if kind == gamesvc.LeaderboardKind_LEADERBOARD_KIND_RATING {
+		kindName = "rating"
+	}
+
+	return fmt.Sprintf("leaderboard:%s:%s:%s", kindName, language, period)
+}
+
+// RatingKey returns a key of all-time rating leaderboard which holds current
+// ratings in the language scope.
+func RatingKey(language string) string {
+	if language == "" {
+		language = AllLanguages
+	}
+
+	return LeaderboardKey(gamesvc.LeaderboardKind_LEADERBOARD_KIND_RATING, language, allTimePeriod)
+}
+
+// LeaderboardUpdate is a change of leaderboards made by a single match in a
+// single language scope.
+type LeaderboardUpdate struct {
+	// Ratings are new absolute ratings of players, written to AllTimeRatingKey.
+	Ratings map[string]float64
+	// RatingDeltas are added to WeeklyRatingKey.
+	RatingDeltas map[string]float64
+	// Winners get a win in AllTimeWinsKey and WeeklyWinsKey.
+	Winners []string
+
+	AllTimeRatingKey string
+	WeeklyRatingKey  string
+	AllTimeWinsKey   string
+	WeeklyWinsKey    string
+}
+
+// LeaderboardScopes returns languages which leaderboards are changed by the match.
+func LeaderboardScopes(m *gamesvc.Match) []string {
+	if m.Langugage == "" || m.Langugage == AllLanguages {
+		return []string{AllLanguages}
+	}
+
+	return []string{AllLanguages, m.Langugage}
+}
+
+// MatchPlayers returns players of all teams in the match.
+func MatchPlayers(m *gamesvc.Match) []*gamesvc.Player {
+	var players []*gamesvc.Player
+	for _, team := range m.Teams {
+		players = append(players, team.Players...)
+	}
+	return players
+}
+
+// NewLeaderboardUpdate calculates the change for the language scope given
+// current ratings of match players.
+func NewLeaderboardUpdate(m *gamesvc.Match, language string, ratings map[string]float64) LeaderboardUpdate {
+	week := WeekOf(time.UnixMilli(m.EndedAtMs))
+	update := LeaderboardUpdate{
+		Ratings:      make(map[string]float64),
+		RatingDeltas: make(map[string]float64),
+
+		AllTimeRatingKey: RatingKey(language),
+		WeeklyRatingKey:  LeaderboardKey(gamesvc.LeaderboardKind_LEADERBOARD_KIND_RATING, language, week),
+		AllTimeWinsKey:   LeaderboardKey(gamesvc.LeaderboardKind_LEADERBOARD_KIND_WINS, language, allTimePeriod),
+		WeeklyWinsKey:    LeaderboardKey(gamesvc.LeaderboardKind_LEADERBOARD_KIND_WINS, language, week),
+	}
+
+	teams := make([]rating.Team, len(m.Teams))
+	for i, team := range m.Teams {
+		playerRatings := make([]float64, len(team.Players))
+		for j, p := range team.Players {
+			playerRatings[j] = ratingOrInitial(ratings, p.Id)
+		}
+
+		teams[i] = rating.Team{
+			Rating: rating.Average(playerRatings...),
+			Score:  float64(team.Stats.GetRights()) - float64(team.Stats.GetPenalties()),
+		}
+	}
+
+	for i, delta := range rating.Deltas(teams) {
+		team := m.Teams[i]
+		for _, p := range team.Players {
+			update.Ratings[p.Id] = ratingOrInitial(ratings, p.Id) + delta
+			update.RatingDeltas[p.Id] = delta
+
+			if team.Id == m.WinnerTeamId {
+				update.Winners = append(update.Winners, p.Id)
+			}
+		}
+	}
+
+	return update
+}
+
+// WeekOf returns ISO week of t, for example "2023-W17".
+func WeekOf(t time.Time) string {
+	year, week := t.UTC().ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
+
+func ratingOrInitial(ratings map[string]float64, playerID string) float64 {
+	r, ok := ratings[playerID]
+	if !ok {
+		return rating.Initial
+	}
+	return r
+}
@@ -0,0 +1,68 @@
+package storage
+
+import (
+	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+)
+
+// MaxPlayerMatches is how many matches are kept in a player's history.
+const MaxPlayerMatches = 100
+
+// PlayerStatsFromMatch returns the statistics each player earned in the match.
+// Backends add them to the stored totals.
+func PlayerStatsFromMatch(m *gamesvc.Match) map[string]*gamesvc.PlayerStats {
+	stats := make(map[string]*gamesvc.PlayerStats)
+	get := func(playerID string) *gamesvc.PlayerStats {
+		s, ok := stats[playerID]
+		if !ok {
+			s = &gamesvc.PlayerStats{PlayerId: playerID}
+			stats[playerID] = s
+		}
+		return s
+	}
+
+	for _, team := range m.Teams {
+		for _, p := range team.Players {
+			s := get(p.Id)
+			s.GamesPlayed += 1
+			if m.WinnerTeamId != "" && team.Id == m.WinnerTeamId {
+				s.Wins += 1
+			}
+		}
+	}
+
+	for _, turn := range m.Turns {
+		if turn.ExplainerId != "" {
+			s := get(turn.ExplainerId)
+			s.WordsExplained += turn.Stats.GetRights()
+			s.WordsMissed += turn.Stats.GetWrongs()
+		}
+
+		if turn.GuesserId != "" {
+			get(turn.GuesserId).WordsGuessed += turn.Stats.GetRights()
+		}
+	}
+
+	return stats
+}
+
+// AddPlayerStats adds delta to total and recalculates accuracy.
+func AddPlayerStats(total, delta *gamesvc.PlayerStats) {
+	total.GamesPlayed += delta.GamesPlayed
+	total.Wins += delta.Wins
+	total.WordsExplained += delta.WordsExplained
+	total.WordsGuessed += delta.WordsGuessed
+	total.WordsMissed += delta.WordsMissed
+	SetAccuracy(total)
+}
+
+// SetAccuracy sets accuracy as a share of explained words out of all words
+// the player had to explain.
+func SetAccuracy(s *gamesvc.PlayerStats) {
+	all := s.WordsExplained + s.WordsMissed
+	if all == 0 {
+		s.Accuracy = 0
+		return
+	}
+
+	s.Accuracy = float32(s.WordsExplained) / float32(all)
+}
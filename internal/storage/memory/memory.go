@@ -2,23 +2,47 @@ package memory
 
 import (
 	"context"
+	"sort"
 	"sync"
+	"time"
 
 	clone "github.com/huandu/go-clone/generic"
 	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	"github.com/knightpp/alias-server/internal/rating"
 	"github.com/knightpp/alias-server/internal/storage"
 )
 
-var _ storage.Player = (*Memory)(nil)
+var _ storage.Storage = (*Memory)(nil)
 
 type Memory struct {
-	players map[string]*gamesvc.Player
-	mu      sync.Mutex
+	players       map[string]*gamesvc.Player
+	playersByID   map[string]*gamesvc.Player
+	matches       map[string]*gamesvc.Match
+	playerMatches map[string][]string
+	playerStats   map[string]*gamesvc.PlayerStats
+	// leaderboards maps leaderboard key to scores of players
+	leaderboards       map[string]map[string]float64
+	leaderboardPlayers map[string]*gamesvc.Player
+	friends            map[string]map[string]bool
+	// friendRequests maps ID of a player to IDs of players who sent them
+	// a request
+	friendRequests map[string]map[string]bool
+	mu             sync.Mutex
 }
 
 func New() *Memory {
 	return &Memory{
-		players: make(map[string]*gamesvc.Player),
+		players:       make(map[string]*gamesvc.Player),
+		playersByID:   make(map[string]*gamesvc.Player),
+		matches:       make(map[string]*gamesvc.Match),
+		playerMatches: make(map[string][]string),
+		playerStats:   make(map[string]*gamesvc.PlayerStats),
+
+		leaderboards:       make(map[string]map[string]float64),
+		leaderboardPlayers: make(map[string]*gamesvc.Player),
+
+		friends:        make(map[string]map[string]bool),
+		friendRequests: make(map[string]map[string]bool),
 	}
 }
 
@@ -27,6 +51,7 @@ func (m *Memory) SetPlayer(ctx context.Context, token string, p *gamesvc.Player)
 	defer m.mu.Unlock()
 
 	m.players[token] = clone.Clone(p)
+	m.playersByID[p.Id] = clone.Clone(p)
 
 	return nil
 }
@@ -42,3 +67,254 @@ func (m *Memory) GetPlayer(ctx context.Context, token string) (*gamesvc.Player,
 
 	return clone.Clone(player), nil
 }
+
+func (m *Memory) GetPlayerByID(ctx context.Context, id string) (*gamesvc.Player, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	player, ok := m.playersByID[id]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+
+	return clone.Clone(player), nil
+}
+
+func (m *Memory) SaveMatch(ctx context.Context, match *gamesvc.Match) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.matches[match.Id] = clone.Clone(match)
+
+	for playerID, delta := range storage.PlayerStatsFromMatch(match) {
+		ids := append([]string{match.Id}, m.playerMatches[playerID]...)
+		if len(ids) > storage.MaxPlayerMatches {
+			ids = ids[:storage.MaxPlayerMatches]
+		}
+		m.playerMatches[playerID] = ids
+
+		total, ok := m.playerStats[playerID]
+		if !ok {
+			total = &gamesvc.PlayerStats{PlayerId: playerID}
+			m.playerStats[playerID] = total
+		}
+		storage.AddPlayerStats(total, delta)
+	}
+
+	return nil
+}
+
+func (m *Memory) GetMatch(ctx context.Context, id string) (*gamesvc.Match, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	match, ok := m.matches[id]
+	if !ok {
+		return nil, storage.ErrMatchNotFound
+	}
+
+	return clone.Clone(match), nil
+}
+
+func (m *Memory) GetPlayerMatches(ctx context.Context, playerID string, limit int) ([]*gamesvc.Match, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ids := m.playerMatches[playerID]
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+
+	matches := make([]*gamesvc.Match, 0, len(ids))
+	for _, id := range ids {
+		matches = append(matches, clone.Clone(m.matches[id]))
+	}
+
+	return matches, nil
+}
+
+func (m *Memory) GetPlayerStats(ctx context.Context, playerID string) (*gamesvc.PlayerStats, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats, ok := m.playerStats[playerID]
+	if !ok {
+		return &gamesvc.PlayerStats{PlayerId: playerID}, nil
+	}
+
+	return clone.Clone(stats), nil
+}
+
+func (m *Memory) UpdateLeaderboards(ctx context.Context, match *gamesvc.Match) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, p := range storage.MatchPlayers(match) {
+		m.leaderboardPlayers[p.Id] = clone.Clone(p)
+	}
+
+	for _, language := range storage.LeaderboardScopes(match) {
+		ratings := m.board(storage.RatingKey(language))
+		update := storage.NewLeaderboardUpdate(match, language, ratings)
+
+		for id, r := range update.Ratings {
+			m.board(update.AllTimeRatingKey)[id] = r
+		}
+		for id, delta := range update.RatingDeltas {
+			m.board(update.WeeklyRatingKey)[id] += delta
+		}
+		for _, id := range update.Winners {
+			m.board(update.AllTimeWinsKey)[id] += 1
+			m.board(update.WeeklyWinsKey)[id] += 1
+		}
+	}
+
+	return nil
+}
+
+func (m *Memory) GetLeaderboard(ctx context.Context, q storage.LeaderboardQuery) ([]*gamesvc.LeaderboardEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	board := m.leaderboards[q.Key(time.Now())]
+
+	ids := make([]string, 0, len(board))
+	for id := range board {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if board[ids[i]] != board[ids[j]] {
+			return board[ids[i]] > board[ids[j]]
+		}
+		return ids[i] > ids[j]
+	})
+
+	if q.Offset >= len(ids) {
+		return nil, nil
+	}
+	ids = ids[q.Offset:]
+	if len(ids) > q.Limit {
+		ids = ids[:q.Limit]
+	}
+
+	entries := make([]*gamesvc.LeaderboardEntry, len(ids))
+	for i, id := range ids {
+		player, ok := m.leaderboardPlayers[id]
+		if !ok {
+			player = &gamesvc.Player{Id: id}
+		}
+
+		entries[i] = &gamesvc.LeaderboardEntry{
+			Rank:   uint32(q.Offset + i + 1),
+			Player: clone.Clone(player),
+			Score:  board[id],
+		}
+	}
+
+	return entries, nil
+}
+
+func (m *Memory) GetRatings(ctx context.Context, language string, playerIDs []string) (map[string]float64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	board := m.leaderboards[storage.RatingKey(language)]
+
+	ratings := make(map[string]float64, len(playerIDs))
+	for _, id := range playerIDs {
+		r, ok := board[id]
+		if !ok {
+			r = rating.Initial
+		}
+		ratings[id] = r
+	}
+
+	return ratings, nil
+}
+
+func (m *Memory) AddFriendRequest(ctx context.Context, fromID, toID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.friends[fromID][toID] {
+		return storage.ErrAlreadyFriends
+	}
+
+	addToSet(m.friendRequests, toID, fromID)
+
+	return nil
+}
+
+func (m *Memory) AcceptFriendRequest(ctx context.Context, playerID, fromID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.friendRequests[playerID][fromID] {
+		return storage.ErrFriendRequestNotFound
+	}
+
+	delete(m.friendRequests[playerID], fromID)
+	delete(m.friendRequests[fromID], playerID)
+	addToSet(m.friends, playerID, fromID)
+	addToSet(m.friends, fromID, playerID)
+
+	return nil
+}
+
+func (m *Memory) RemoveFriend(ctx context.Context, playerID, friendID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.friends[playerID], friendID)
+	delete(m.friends[friendID], playerID)
+	delete(m.friendRequests[playerID], friendID)
+	delete(m.friendRequests[friendID], playerID)
+
+	return nil
+}
+
+func (m *Memory) GetFriendList(ctx context.Context, playerID string) (*storage.FriendList, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	list := &storage.FriendList{}
+	for id := range m.friends[playerID] {
+		list.Friends = append(list.Friends, id)
+	}
+	for id := range m.friendRequests[playerID] {
+		list.Incoming = append(list.Incoming, id)
+	}
+	for id, from := range m.friendRequests {
+		if from[playerID] {
+			list.Outgoing = append(list.Outgoing, id)
+		}
+	}
+
+	sort.Strings(list.Friends)
+	sort.Strings(list.Incoming)
+	sort.Strings(list.Outgoing)
+
+	return list, nil
+}
+
+func (m *Memory) board(key string) map[string]float64 {
+	board, ok := m.leaderboards[key]
+	if !ok {
+		board = make(map[string]float64)
+		m.leaderboards[key] = board
+	}
+	return board
+}
+
+func addToSet(sets map[string]map[string]bool, key, value string) {
+	set, ok := sets[key]
+	if !ok {
+		set = make(map[string]bool)
+		sets[key] = set
+	}
+	set[value] = true
+}
+
+func (m *Memory) Ping(context.Context) error {
+	return nil
+}
@@ -69,6 +69,53 @@ func (_c *Player_GetPlayer_Call) Return(_a0 *gamesvc.Player, _a1 error) *Player_
 	return _c
 }
 
+// GetPlayerByID provides a mock function with given fields: ctx, id
+func (_m *Player) GetPlayerByID(ctx context.Context, id string) (*gamesvc.Player, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *gamesvc.Player
+	if rf, ok := ret.Get(0).(func(context.Context, string) *gamesvc.Player); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*gamesvc.Player)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Player_GetPlayerByID_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetPlayerByID'
+type Player_GetPlayerByID_Call struct {
+	*mock.Call
+}
+
+// GetPlayerByID is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id string
+func (_e *Player_Expecter) GetPlayerByID(ctx interface{}, id interface{}) *Player_GetPlayerByID_Call {
+	return &Player_GetPlayerByID_Call{Call: _e.mock.On("GetPlayerByID", ctx, id)}
+}
+
+func (_c *Player_GetPlayerByID_Call) Run(run func(ctx context.Context, id string)) *Player_GetPlayerByID_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Player_GetPlayerByID_Call) Return(_a0 *gamesvc.Player, _a1 error) *Player_GetPlayerByID_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
 // SetPlayer provides a mock function with given fields: ctx, token, p
 func (_m *Player) SetPlayer(ctx context.Context, token string, p *gamesvc.Player) error {
 	ret := _m.Called(ctx, token, p)
@@ -4,49 +4,66 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
+	"strconv"
 	"time"
 
 	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	"github.com/knightpp/alias-server/internal/rating"
 	"github.com/knightpp/alias-server/internal/storage"
 	"github.com/redis/go-redis/v9"
 	"google.golang.org/protobuf/proto"
 )
 
-var _ storage.Player = (*redisImpl)(nil)
+var _ storage.Storage = (*redisImpl)(nil)
+
+// maxTxRetries is how many times a transaction is retried if keys it
+// watches change.
+const maxTxRetries = 10
 
 type redisImpl struct {
 	db *redis.Client
 }
 
-func New(addr string) storage.Player {
+func New(addr string) storage.Storage {
 	rdb := redis.NewClient(&redis.Options{
 		Addr:     addr,
 		Password: "",
 		DB:       0,
 	})
+	rdb.AddHook(tracingHook{})
 	return &redisImpl{
 		db: rdb,
 	}
 }
 
-func NewFromURL(url string) (storage.Player, error) {
+func NewFromURL(url string) (storage.Storage, error) {
 	opts, err := redis.ParseURL(url)
 	if err != nil {
 		return nil, fmt.Errorf("parse redis url: %w", err)
 	}
 
 	rdb := redis.NewClient(opts)
+	rdb.AddHook(tracingHook{})
 	return &redisImpl{db: rdb}, nil
 }
 
+func (r *redisImpl) Ping(ctx context.Context) error {
+	return r.db.Ping(ctx).Err()
+}
+
 func (r *redisImpl) SetPlayer(ctx context.Context, token string, p *gamesvc.Player) error {
 	playerBytes, err := proto.Marshal(p)
 	if err != nil {
 		return fmt.Errorf("marshal player as protobuf: %w", err)
 	}
 
-	cmd := r.db.Set(ctx, token, playerBytes, (24*time.Hour)*30)
-	return cmd.Err()
+	_, err = r.db.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, token, playerBytes, playerTTL)
+		pipe.Set(ctx, playerKey(p.Id), playerBytes, playerTTL)
+		return nil
+	})
+	return err
 }
 
 func (r *redisImpl) GetPlayer(ctx context.Context, token string) (*gamesvc.Player, error) {
@@ -74,3 +91,434 @@ func (r *redisImpl) GetPlayer(ctx context.Context, token string) (*gamesvc.Playe
 
 	return playerPb, nil
 }
+
+func (r *redisImpl) GetPlayerByID(ctx context.Context, id string) (*gamesvc.Player, error) {
+	if id == "" {
+		return nil, errors.New("error: player id is empty")
+	}
+
+	playerBytes, err := r.db.Get(ctx, playerKey(id)).Bytes()
+	if err != nil {
+		switch {
+		case errors.Is(err, redis.Nil):
+			return nil, storage.ErrNotFound
+		default:
+			return nil, fmt.Errorf("get redis bytes: %w", err)
+		}
+	}
+
+	playerPb := &gamesvc.Player{}
+	err = proto.Unmarshal(playerBytes, playerPb)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal proto: %w", err)
+	}
+
+	return playerPb, nil
+}
+
+func (r *redisImpl) SaveMatch(ctx context.Context, m *gamesvc.Match) error {
+	matchBytes, err := proto.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("marshal match as protobuf: %w", err)
+	}
+
+	_, err = r.db.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, matchKey(m.Id), matchBytes, 0)
+
+		for playerID, delta := range storage.PlayerStatsFromMatch(m) {
+			pipe.LPush(ctx, playerMatchesKey(playerID), m.Id)
+			pipe.LTrim(ctx, playerMatchesKey(playerID), 0, storage.MaxPlayerMatches-1)
+
+			statsKey := playerStatsKey(playerID)
+			pipe.HIncrBy(ctx, statsKey, "games_played", int64(delta.GamesPlayed))
+			pipe.HIncrBy(ctx, statsKey, "wins", int64(delta.Wins))
+			pipe.HIncrBy(ctx, statsKey, "words_explained", int64(delta.WordsExplained))
+			pipe.HIncrBy(ctx, statsKey, "words_guessed", int64(delta.WordsGuessed))
+			pipe.HIncrBy(ctx, statsKey, "words_missed", int64(delta.WordsMissed))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("save match: %w", err)
+	}
+
+	return nil
+}
+
+func (r *redisImpl) GetMatch(ctx context.Context, id string) (*gamesvc.Match, error) {
+	if id == "" {
+		return nil, errors.New("error: match id is empty")
+	}
+
+	matchBytes, err := r.db.Get(ctx, matchKey(id)).Bytes()
+	if err != nil {
+		switch {
+		case errors.Is(err, redis.Nil):
+			return nil, storage.ErrMatchNotFound
+		default:
+			return nil, fmt.Errorf("get redis bytes: %w", err)
+		}
+	}
+
+	matchPb := &gamesvc.Match{}
+	err = proto.Unmarshal(matchBytes, matchPb)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal proto: %w", err)
+	}
+
+	return matchPb, nil
+}
+
+func (r *redisImpl) GetPlayerMatches(ctx context.Context, playerID string, limit int) ([]*gamesvc.Match, error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+
+	ids, err := r.db.LRange(ctx, playerMatchesKey(playerID), 0, int64(limit)-1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("get match ids: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = matchKey(id)
+	}
+
+	values, err := r.db.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("get matches: %w", err)
+	}
+
+	matches := make([]*gamesvc.Match, 0, len(values))
+	for _, value := range values {
+		// nil if the match was deleted
+		str, ok := value.(string)
+		if !ok {
+			continue
+		}
+
+		matchPb := &gamesvc.Match{}
+		err = proto.Unmarshal([]byte(str), matchPb)
+		if err != nil {
+			return nil, fmt.Errorf("unmarshal proto: %w", err)
+		}
+
+		matches = append(matches, matchPb)
+	}
+
+	return matches, nil
+}
+
+func (r *redisImpl) GetPlayerStats(ctx context.Context, playerID string) (*gamesvc.PlayerStats, error) {
+	fields, err := r.db.HGetAll(ctx, playerStatsKey(playerID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("get player stats: %w", err)
+	}
+
+	stats := &gamesvc.PlayerStats{PlayerId: playerID}
+	for field, dst := range map[string]*uint32{
+		"games_played":    &stats.GamesPlayed,
+		"wins":            &stats.Wins,
+		"words_explained": &stats.WordsExplained,
+		"words_guessed":   &stats.WordsGuessed,
+		"words_missed":    &stats.WordsMissed,
+	} {
+		value, ok := fields[field]
+		if !ok {
+			continue
+		}
+
+		n, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", field, err)
+		}
+
+		*dst = uint32(n)
+	}
+	storage.SetAccuracy(stats)
+
+	return stats, nil
+}
+
+func (r *redisImpl) UpdateLeaderboards(ctx context.Context, m *gamesvc.Match) error {
+	players := storage.MatchPlayers(m)
+	playerIDs := make([]string, len(players))
+	for i, p := range players {
+		playerIDs[i] = p.Id
+	}
+
+	scopes := storage.LeaderboardScopes(m)
+	ratingKeys := make([]string, len(scopes))
+	for i, language := range scopes {
+		ratingKeys[i] = storage.RatingKey(language)
+	}
+
+	// new ratings are calculated from current ones, the transaction fails
+	// if another match changes them in between
+	update := func(tx *redis.Tx) error {
+		updates := make([]storage.LeaderboardUpdate, 0, len(scopes))
+		for _, language := range scopes {
+			ratings, err := getRatings(ctx, tx, language, playerIDs)
+			if err != nil {
+				return err
+			}
+
+			updates = append(updates, storage.NewLeaderboardUpdate(m, language, ratings))
+		}
+
+		_, err := tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, p := range players {
+				playerBytes, err := proto.Marshal(p)
+				if err != nil {
+					return fmt.Errorf("marshal player as protobuf: %w", err)
+				}
+
+				pipe.HSet(ctx, leaderboardPlayersKey, p.Id, playerBytes)
+			}
+
+			for _, update := range updates {
+				for id, score := range update.Ratings {
+					pipe.ZAdd(ctx, update.AllTimeRatingKey, redis.Z{Score: score, Member: id})
+				}
+				for id, delta := range update.RatingDeltas {
+					pipe.ZIncrBy(ctx, update.WeeklyRatingKey, delta, id)
+				}
+				for _, id := range update.Winners {
+					pipe.ZIncrBy(ctx, update.AllTimeWinsKey, 1, id)
+					pipe.ZIncrBy(ctx, update.WeeklyWinsKey, 1, id)
+				}
+
+				pipe.Expire(ctx, update.WeeklyRatingKey, storage.WeeklyLeaderboardTTL)
+				pipe.Expire(ctx, update.WeeklyWinsKey, storage.WeeklyLeaderboardTTL)
+			}
+
+			return nil
+		})
+		return err
+	}
+
+	for i := 0; i < maxTxRetries; i++ {
+		err := r.db.Watch(ctx, update, ratingKeys...)
+		if errors.Is(err, redis.TxFailedErr) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("update leaderboards: %w", err)
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("update leaderboards: %w", redis.TxFailedErr)
+}
+
+func (r *redisImpl) GetLeaderboard(ctx context.Context, q storage.LeaderboardQuery) ([]*gamesvc.LeaderboardEntry, error) {
+	if q.Limit <= 0 {
+		return nil, nil
+	}
+
+	key := q.Key(time.Now())
+	scores, err := r.db.ZRevRangeWithScores(ctx, key, int64(q.Offset), int64(q.Offset+q.Limit-1)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("get leaderboard: %w", err)
+	}
+	if len(scores) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]string, len(scores))
+	for i, z := range scores {
+		ids[i] = z.Member.(string)
+	}
+
+	values, err := r.db.HMGet(ctx, leaderboardPlayersKey, ids...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("get leaderboard players: %w", err)
+	}
+
+	entries := make([]*gamesvc.LeaderboardEntry, len(scores))
+	for i, z := range scores {
+		player := &gamesvc.Player{Id: ids[i]}
+		if str, ok := values[i].(string); ok {
+			err = proto.Unmarshal([]byte(str), player)
+			if err != nil {
+				return nil, fmt.Errorf("unmarshal proto: %w", err)
+			}
+		}
+
+		entries[i] = &gamesvc.LeaderboardEntry{
+			Rank:   uint32(q.Offset + i + 1),
+			Player: player,
+			Score:  z.Score,
+		}
+	}
+
+	return entries, nil
+}
+
+func (r *redisImpl) GetRatings(ctx context.Context, language string, playerIDs []string) (map[string]float64, error) {
+	return getRatings(ctx, r.db, language, playerIDs)
+}
+
+// getRatings returns ratings of players, players without a rating have the
+// initial one.
+func getRatings(ctx context.Context, c redis.Cmdable, language string, playerIDs []string) (map[string]float64, error) {
+	ratings := make(map[string]float64, len(playerIDs))
+	if len(playerIDs) == 0 {
+		return ratings, nil
+	}
+
+	// ZMSCORE can't tell a missing member from a rating of 0
+	key := storage.RatingKey(language)
+	cmds := make([]*redis.FloatCmd, len(playerIDs))
+	_, err := c.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, id := range playerIDs {
+			cmds[i] = pipe.ZScore(ctx, key, id)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("get ratings: %w", err)
+	}
+
+	for i, id := range playerIDs {
+		score, err := cmds[i].Result()
+		switch {
+		case errors.Is(err, redis.Nil):
+			score = rating.Initial
+		case err != nil:
+			return nil, fmt.Errorf("get rating: %w", err)
+		}
+
+		ratings[id] = score
+	}
+
+	return ratings, nil
+}
+
+func (r *redisImpl) AddFriendRequest(ctx context.Context, fromID, toID string) error {
+	isFriend, err := r.db.SIsMember(ctx, friendsKey(fromID), toID).Result()
+	if err != nil {
+		return fmt.Errorf("check friends: %w", err)
+	}
+	if isFriend {
+		return storage.ErrAlreadyFriends
+	}
+
+	_, err = r.db.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SAdd(ctx, incomingFriendRequestsKey(toID), fromID)
+		pipe.SAdd(ctx, outgoingFriendRequestsKey(fromID), toID)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("add friend request: %w", err)
+	}
+
+	return nil
+}
+
+func (r *redisImpl) AcceptFriendRequest(ctx context.Context, playerID, fromID string) error {
+	hasRequest, err := r.db.SIsMember(ctx, incomingFriendRequestsKey(playerID), fromID).Result()
+	if err != nil {
+		return fmt.Errorf("check friend request: %w", err)
+	}
+	if !hasRequest {
+		return storage.ErrFriendRequestNotFound
+	}
+
+	_, err = r.db.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		removeFriendRequests(ctx, pipe, playerID, fromID)
+		pipe.SAdd(ctx, friendsKey(playerID), fromID)
+		pipe.SAdd(ctx, friendsKey(fromID), playerID)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("accept friend request: %w", err)
+	}
+
+	return nil
+}
+
+func (r *redisImpl) RemoveFriend(ctx context.Context, playerID, friendID string) error {
+	_, err := r.db.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		removeFriendRequests(ctx, pipe, playerID, friendID)
+		pipe.SRem(ctx, friendsKey(playerID), friendID)
+		pipe.SRem(ctx, friendsKey(friendID), playerID)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("remove friend: %w", err)
+	}
+
+	return nil
+}
+
+func (r *redisImpl) GetFriendList(ctx context.Context, playerID string) (*storage.FriendList, error) {
+	var friends, incoming, outgoing *redis.StringSliceCmd
+	_, err := r.db.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		friends = pipe.SMembers(ctx, friendsKey(playerID))
+		incoming = pipe.SMembers(ctx, incomingFriendRequestsKey(playerID))
+		outgoing = pipe.SMembers(ctx, outgoingFriendRequestsKey(playerID))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get friend list: %w", err)
+	}
+
+	list := &storage.FriendList{
+		Friends:  friends.Val(),
+		Incoming: incoming.Val(),
+		Outgoing: outgoing.Val(),
+	}
+	sort.Strings(list.Friends)
+	sort.Strings(list.Incoming)
+	sort.Strings(list.Outgoing)
+
+	return list, nil
+}
+
+// removeFriendRequests removes requests between players in both directions.
+func removeFriendRequests(ctx context.Context, pipe redis.Pipeliner, a, b string) {
+	pipe.SRem(ctx, incomingFriendRequestsKey(a), b)
+	pipe.SRem(ctx, outgoingFriendRequestsKey(b), a)
+	pipe.SRem(ctx, incomingFriendRequestsKey(b), a)
+	pipe.SRem(ctx, outgoingFriendRequestsKey(a), b)
+}
+
+const (
+	leaderboardPlayersKey = "leaderboard:players"
+
+	playerTTL = (24 * time.Hour) * 30
+)
+
+func playerKey(id string) string {
+	return "player:" + id
+}
+
+func friendsKey(playerID string) string {
+	return "player:" + playerID + ":friends"
+}
+
+func incomingFriendRequestsKey(playerID string) string {
+	return "player:" + playerID + ":friend_requests:incoming"
+}
+
+func outgoingFriendRequestsKey(playerID string) string {
+	return "player:" + playerID + ":friend_requests:outgoing"
+}
+
+func matchKey(id string) string {
+	return "match:" + id
+}
+
+func playerMatchesKey(playerID string) string {
+	return "player:" + playerID + ":matches"
+}
+
+func playerStatsKey(playerID string) string {
+	return "player:" + playerID + ":stats"
+}
@@ -7,10 +7,67 @@ import (
 	gamesvc "github.com/knightpp/alias-proto/go/game_service"
 )
 
-var ErrNotFound = errors.New("player not found")
+var (
+	ErrNotFound      = errors.New("player not found")
+	ErrMatchNotFound = errors.New("match not found")
+
+	ErrFriendRequestNotFound = errors.New("friend request not found")
+	ErrAlreadyFriends        = errors.New("players are already friends")
+)
+
+type Storage interface {
+	Player
+	Match
+	Leaderboard
+	Friends
+	Pinger
+}
+
+//go:generate mockery --name Pinger --with-expecter
+type Pinger interface {
+	// Ping checks that the storage is reachable.
+	Ping(ctx context.Context) error
+}
 
 //go:generate mockery --name Player --with-expecter
 type Player interface {
 	SetPlayer(ctx context.Context, token string, p *gamesvc.Player) error
 	GetPlayer(ctx context.Context, token string) (*gamesvc.Player, error)
+	GetPlayerByID(ctx context.Context, id string) (*gamesvc.Player, error)
+}
+
+//go:generate mockery --name Match --with-expecter
+type Match interface {
+	// SaveMatch stores a finished match and adds it to the history and
+	// statistics of every player that took part in it.
+	SaveMatch(ctx context.Context, m *gamesvc.Match) error
+	GetMatch(ctx context.Context, id string) (*gamesvc.Match, error)
+	// GetPlayerMatches returns up to limit matches of the player, newest first.
+	GetPlayerMatches(ctx context.Context, playerID string, limit int) ([]*gamesvc.Match, error)
+	GetPlayerStats(ctx context.Context, playerID string) (*gamesvc.PlayerStats, error)
+}
+
+//go:generate mockery --name Leaderboard --with-expecter
+type Leaderboard interface {
+	// UpdateLeaderboards adds results of a finished match to the leaderboards
+	// and updates ratings of its players.
+	UpdateLeaderboards(ctx context.Context, m *gamesvc.Match) error
+	GetLeaderboard(ctx context.Context, q LeaderboardQuery) ([]*gamesvc.LeaderboardEntry, error)
+	// GetRatings returns ratings of players in the language scope. Players
+	// without a rating get rating.Initial.
+	GetRatings(ctx context.Context, language string, playerIDs []string) (map[string]float64, error)
+}
+
+//go:generate mockery --name Friends --with-expecter
+type Friends interface {
+	// AddFriendRequest stores a request from one player to another. It returns
+	// ErrAlreadyFriends if players are friends.
+	AddFriendRequest(ctx context.Context, fromID, toID string) error
+	// AcceptFriendRequest makes players friends if there is a request from
+	// fromID to playerID.
+	AcceptFriendRequest(ctx context.Context, playerID, fromID string) error
+	// RemoveFriend removes friendship and requests between players in both
+	// directions.
+	RemoveFriend(ctx context.Context, playerID, friendID string) error
+	GetFriendList(ctx context.Context, playerID string) (*FriendList, error)
 }
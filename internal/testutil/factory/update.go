@@ -46,6 +46,19 @@ func (r *Room) Build() *gamesvc.Message {
 		panic("LeaderId must not be empty")
 	}
 
+	var count int
+	for _, t := range msg.Room.Teams {
+		if t.PlayerA != nil {
+			count += 1
+		}
+		if t.PlayerB != nil {
+			count += 1
+		}
+	}
+	msg.Room.PlayerCount = uint32(count)
+	msg.Room.Capacity = uint32(2 * len(msg.Room.Teams))
+	msg.Room.HasPassword = r.req.Password != nil
+
 	return &gamesvc.Message{
 		Message: &gamesvc.Message_UpdateRoom{
 			UpdateRoom: msg,
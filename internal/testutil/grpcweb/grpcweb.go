@@ -0,0 +1,148 @@
+// Package grpcweb is a gRPC-Web client that makes requests the way browsers
+// do, over HTTP/1.1.
+package grpcweb
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const trailerFlag = 0x80
+
+type Client struct {
+	url  string
+	http *http.Client
+}
+
+// NewClient returns a client of the server at url, e.g. http://host:port.
+func NewClient(url string) *Client {
+	return &Client{
+		url:  url,
+		http: &http.Client{},
+	}
+}
+
+// Invoke calls a unary method, e.g. /game_service.GameService/ListRooms.
+func (c *Client) Invoke(ctx context.Context, method string, md metadata.MD, req, resp proto.Message) error {
+	stream, err := c.Stream(ctx, method, md, req)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	err = stream.Recv(resp)
+	if errors.Is(err, io.EOF) {
+		return status.Error(codes.Internal, "response is empty")
+	}
+	return err
+}
+
+// Stream calls a server streaming method.
+func (c *Client) Stream(ctx context.Context, method string, md metadata.MD, req proto.Message) (*Stream, error) {
+	body, err := proto.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	frame := make([]byte, 5, 5+len(body))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(body)))
+	frame = append(frame, body...)
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url+method, bytes.NewReader(frame))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/grpc-web+proto")
+	httpReq.Header.Set("X-Grpc-Web", "1")
+	for key, values := range md {
+		for _, value := range values {
+			httpReq.Header.Add(key, value)
+		}
+	}
+
+	httpResp, err := c.http.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		httpResp.Body.Close()
+		return nil, fmt.Errorf("unexpected http status %s", httpResp.Status)
+	}
+
+	// a response without messages has status in headers
+	if code := httpResp.Header.Get("Grpc-Status"); code != "" {
+		httpResp.Body.Close()
+		return nil, statusError(code, httpResp.Header.Get("Grpc-Message"))
+	}
+
+	return &Stream{
+		body:   httpResp.Body,
+		reader: bufio.NewReader(httpResp.Body),
+	}, nil
+}
+
+type Stream struct {
+	body   io.Closer
+	reader *bufio.Reader
+}
+
+// Recv reads the next message. It returns io.EOF when the stream ends with
+// OK status, or the status as error.
+func (s *Stream) Recv(msg proto.Message) error {
+	var header [5]byte
+	_, err := io.ReadFull(s.reader, header[:])
+	if err != nil {
+		return fmt.Errorf("read frame header: %w", err)
+	}
+
+	payload := make([]byte, binary.BigEndian.Uint32(header[1:]))
+	_, err = io.ReadFull(s.reader, payload)
+	if err != nil {
+		return fmt.Errorf("read frame: %w", err)
+	}
+
+	if header[0]&trailerFlag == 0 {
+		return proto.Unmarshal(payload, msg)
+	}
+
+	trailer, err := textproto.NewReader(bufio.NewReader(io.MultiReader(
+		bytes.NewReader(payload),
+		strings.NewReader("\r\n"),
+	))).ReadMIMEHeader()
+	if err != nil {
+		return fmt.Errorf("read trailer: %w", err)
+	}
+
+	err = statusError(trailer.Get("Grpc-Status"), trailer.Get("Grpc-Message"))
+	if err == nil {
+		return io.EOF
+	}
+	return err
+}
+
+func (s *Stream) Close() error {
+	return s.body.Close()
+}
+
+func statusError(code, msg string) error {
+	c, err := strconv.Atoi(code)
+	if err != nil {
+		return fmt.Errorf("parse grpc status %q: %w", code, err)
+	}
+
+	return status.Error(codes.Code(c), msg)
+}
@@ -2,7 +2,9 @@ package testserver
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 
 	clone "github.com/huandu/go-clone/generic"
 	gamesvc "github.com/knightpp/alias-proto/go/game_service"
@@ -53,9 +55,11 @@ func (tp *TestPlayer) Join(roomID string) (*TestPlayerInRoom, error) {
 
 	playerInRoom := &TestPlayerInRoom{
 		done:   make(chan struct{}),
+		closed: make(chan struct{}),
 		C:      make(chan *gamesvc.Message),
 		logger: tp.log.With().Str("room.id", roomID).Logger(),
 		sock:   sock,
+		client: tp.client,
 		player: tp.player,
 		cancel: cancel,
 	}
@@ -68,7 +72,9 @@ func (tp *TestPlayer) Join(roomID string) (*TestPlayerInRoom, error) {
 		defer ginkgo.GinkgoRecover()
 
 		err := playerInRoom.Start()
-		if status.Code(err) == codes.Canceled {
+		close(playerInRoom.closed)
+		// server ends the stream when the player is kicked or the room is closed
+		if status.Code(err) == codes.Canceled || errors.Is(err, io.EOF) {
 			return
 		}
 
@@ -97,3 +103,39 @@ func (tp *TestPlayer) CreateRoomAndJoin(ctx context.Context, req *gamesvc.Create
 
 	return tp.Join(roomID)
 }
+
+func (tp *TestPlayer) QuickMatch(ctx context.Context, req *gamesvc.QuickMatchRequest) (gamesvc.GameService_QuickMatchClient, error) {
+	ctx = metadata.AppendToOutgoingContext(ctx, mdkey.Auth, tp.authToken)
+
+	return tp.client.QuickMatch(ctx, req)
+}
+
+func (tp *TestPlayer) Client() gamesvc.GameServiceClient {
+	return tp.client
+}
+
+// Token returns the auth token of the player.
+func (tp *TestPlayer) Token() string {
+	return tp.authToken
+}
+
+// AuthContext returns ctx that authenticates requests as the player.
+func (tp *TestPlayer) AuthContext(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, mdkey.Auth, tp.authToken)
+}
+
+// Notifications opens the notifications stream and waits until the server
+// has subscribed it.
+func (tp *TestPlayer) Notifications(ctx context.Context) (gamesvc.GameService_NotificationsClient, error) {
+	stream, err := tp.client.Notifications(tp.AuthContext(ctx), &gamesvc.NotificationsRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = stream.Header()
+	if err != nil {
+		return nil, fmt.Errorf("wait for subscription: %w", err)
+	}
+
+	return stream, nil
+}
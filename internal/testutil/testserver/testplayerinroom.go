@@ -14,10 +14,12 @@ type TestPlayerInRoom struct {
 	logger zerolog.Logger
 
 	sock   gamesvc.GameService_JoinClient
+	client gamesvc.GameServiceClient
 	player *gamesvc.Player
 
 	once   sync.Once
 	done   chan struct{}
+	closed chan struct{}
 	cancel func()
 }
 
@@ -85,6 +87,11 @@ func (ctp *TestPlayerInRoom) NextMsgUnpack(ctx context.Context) any {
 	}
 }
 
+// Closed is closed when the server ends the stream.
+func (ctp *TestPlayerInRoom) Closed() <-chan struct{} {
+	return ctp.closed
+}
+
 func (ctp *TestPlayerInRoom) ID() string {
 	return ctp.player.Id
 }
@@ -97,6 +104,10 @@ func (ctp *TestPlayerInRoom) Sock() gamesvc.GameService_JoinClient {
 	return ctp.sock
 }
 
+func (ctp *TestPlayerInRoom) Client() gamesvc.GameServiceClient {
+	return ctp.client
+}
+
 func (ctp *TestPlayerInRoom) Cancel() {
 	ctp.cancel()
 	ctp.once.Do(func() {
@@ -182,3 +193,84 @@ func (ctp *TestPlayerInRoom) EndGame() error {
 		Message: &gamesvc.Message_EndGame{EndGame: &gamesvc.MsgEndGame{}},
 	})
 }
+
+func (ctp *TestPlayerInRoom) Chat(text string) error {
+	return ctp.sock.Send(&gamesvc.Message{
+		Message: &gamesvc.Message_Chat{
+			Chat: &gamesvc.MsgChat{
+				Text: text,
+			},
+		},
+	})
+}
+
+func (ctp *TestPlayerInRoom) Ping(timestamp int64) error {
+	return ctp.sock.Send(&gamesvc.Message{
+		Message: &gamesvc.Message_Ping{
+			Ping: &gamesvc.MsgPing{
+				Timestamp: timestamp,
+			},
+		},
+	})
+}
+
+func (ctp *TestPlayerInRoom) Pong(timestamp int64) error {
+	return ctp.sock.Send(&gamesvc.Message{
+		Message: &gamesvc.Message_Pong{
+			Pong: &gamesvc.MsgPong{
+				Timestamp: timestamp,
+			},
+		},
+	})
+}
+
+func (ctp *TestPlayerInRoom) RequestSnapshot() error {
+	return ctp.sock.Send(&gamesvc.Message{
+		Message: &gamesvc.Message_RequestSnapshot{
+			RequestSnapshot: &gamesvc.MsgRequestSnapshot{},
+		},
+	})
+}
+
+func (ctp *TestPlayerInRoom) Pause() error {
+	return ctp.sock.Send(&gamesvc.Message{
+		Message: &gamesvc.Message_Pause{Pause: &gamesvc.MsgPause{}},
+	})
+}
+
+func (ctp *TestPlayerInRoom) Resume() error {
+	return ctp.sock.Send(&gamesvc.Message{
+		Message: &gamesvc.Message_Resume{Resume: &gamesvc.MsgResume{}},
+	})
+}
+
+func (ctp *TestPlayerInRoom) ProposeRematch(swapTurnOrder, shuffleTeams bool) error {
+	return ctp.sock.Send(&gamesvc.Message{
+		Message: &gamesvc.Message_ProposeRematch{
+			ProposeRematch: &gamesvc.MsgProposeRematch{
+				SwapTurnOrder: swapTurnOrder,
+				ShuffleTeams:  shuffleTeams,
+			},
+		},
+	})
+}
+
+func (ctp *TestPlayerInRoom) VoteRematch(accept bool) error {
+	return ctp.sock.Send(&gamesvc.Message{
+		Message: &gamesvc.Message_RematchVote{
+			RematchVote: &gamesvc.MsgRematchVote{
+				Accept: accept,
+			},
+		},
+	})
+}
+
+func (ctp *TestPlayerInRoom) React(emoji string) error {
+	return ctp.sock.Send(&gamesvc.Message{
+		Message: &gamesvc.Message_Reaction{
+			Reaction: &gamesvc.MsgReaction{
+				Emoji: emoji,
+			},
+		},
+	})
+}
@@ -2,37 +2,84 @@ package testserver
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net"
+	"net/http"
 
 	"github.com/google/uuid"
+	adminsvc "github.com/knightpp/alias-proto/go/admin_service"
 	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	"github.com/knightpp/alias-proto/go/mdkey"
+	"github.com/knightpp/alias-server/internal/adminservice"
+	"github.com/knightpp/alias-server/internal/game"
+	"github.com/knightpp/alias-server/internal/logging"
+	"github.com/knightpp/alias-server/internal/ratelimit"
 	"github.com/knightpp/alias-server/internal/server"
 	"github.com/knightpp/alias-server/internal/storage"
 	"github.com/knightpp/alias-server/internal/storage/memory"
+	"github.com/knightpp/alias-server/internal/web"
+	"github.com/knightpp/alias-server/internal/wsgateway"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/rs/zerolog"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 )
 
-const TestUUID = "00000000-0000-0000-0000-000000000000"
+const (
+	TestUUID   = "00000000-0000-0000-0000-000000000000"
+	AdminToken = "admin-token"
+)
 
 type TestServer struct {
 	playerDB storage.Player
 	addr     string
+	creds    credentials.TransportCredentials
 	service  *server.GameService
 	log      zerolog.Logger
 }
 
+type Options struct {
+	// TLS enables TLS on the server.
+	TLS *tls.Config
+	// ClientTLS is used by clients, they connect in plaintext if it's nil.
+	ClientTLS *tls.Config
+	// AdminClientCert requires client certificates in the admin service.
+	AdminClientCert bool
+	// Web serves gRPC-Web and the WebSocket gateway with h2c, it can't be
+	// used with TLS.
+	Web *web.Options
+	// Game replaces default options of the game.
+	Game *game.Options
+	// RateLimit enables limits of RPCs.
+	RateLimit *ratelimit.Options
+}
+
 func CreateAndStart() (*TestServer, error) {
+	return CreateAndStartWithOptions(Options{})
+}
+
+func CreateAndStartWithOptions(opts Options) (*TestServer, error) {
 	playerDB := memory.New()
 	log := zerolog.New(zerolog.TestWriter{
 		T:     GinkgoT(),
 		Frame: 4,
 	})
-	gameServer := server.New(log, playerDB)
+	serviceOpts := server.DefaultOptions()
+	if opts.Game != nil {
+		serviceOpts.Game = *opts.Game
+	}
+	gameServer := server.New(log, playerDB, serviceOpts)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	DeferCleanup(cancel)
+	go gameServer.Start(ctx)
 
 	lis, err := net.Listen("tcp", "127.0.0.1:0")
 	if err != nil {
@@ -41,25 +88,88 @@ func CreateAndStart() (*TestServer, error) {
 
 	log.Info().Str("addr", lis.Addr().String()).Msg("starting GRPC server")
 
-	grpcServer := grpc.NewServer()
+	streamInterceptors := []grpc.StreamServerInterceptor{
+		otelgrpc.StreamServerInterceptor(),
+		logging.StreamServerInterceptor(log),
+	}
+	unaryInterceptors := []grpc.UnaryServerInterceptor{
+		otelgrpc.UnaryServerInterceptor(),
+		logging.UnaryServerInterceptor(log),
+	}
+	if opts.RateLimit != nil {
+		limiter := ratelimit.New(log, playerDB, *opts.RateLimit)
+		streamInterceptors = append(streamInterceptors, limiter.StreamServerInterceptor())
+		unaryInterceptors = append(unaryInterceptors, limiter.UnaryServerInterceptor())
+	}
+
+	serverOpts := []grpc.ServerOption{
+		grpc.ChainStreamInterceptor(streamInterceptors...),
+		grpc.ChainUnaryInterceptor(unaryInterceptors...),
+	}
+	if opts.TLS != nil {
+		serverOpts = append(serverOpts, grpc.Creds(credentials.NewTLS(opts.TLS)))
+	}
+
+	grpcServer := grpc.NewServer(serverOpts...)
 	gamesvc.RegisterGameServiceServer(grpcServer, gameServer)
+	adminsvc.RegisterAdminServiceServer(grpcServer, adminservice.New(gameServer.Game(), playerDB, adminservice.Options{
+		Token:             AdminToken,
+		RequireClientCert: opts.AdminClientCert,
+	}))
+
+	creds := insecure.NewCredentials()
+	if opts.ClientTLS != nil {
+		creds = credentials.NewTLS(opts.ClientTLS)
+	}
 
-	go func() {
-		_ = grpcServer.Serve(lis)
-	}()
+	if opts.Web != nil {
+		wsOpts := wsgateway.DefaultOptions()
+		wsOpts.AllowedOrigins = opts.Web.AllowedOrigins
+		wsOpts.Interceptors = streamInterceptors
 
-	DeferCleanup(func() {
-		grpcServer.GracefulStop()
-	})
+		mux := http.NewServeMux()
+		mux.Handle("/ws/join", wsgateway.New(log, gameServer.Join, wsOpts))
+		mux.Handle("/", web.Handler(grpcServer, *opts.Web))
+
+		httpServer := &http.Server{
+			Handler: h2c.NewHandler(mux, &http2.Server{}),
+		}
+		go func() {
+			_ = httpServer.Serve(lis)
+		}()
+
+		DeferCleanup(func() {
+			_ = httpServer.Close()
+			grpcServer.Stop()
+		})
+	} else {
+		go func() {
+			_ = grpcServer.Serve(lis)
+		}()
+
+		DeferCleanup(func() {
+			grpcServer.GracefulStop()
+		})
+	}
 
 	return &TestServer{
 		playerDB: playerDB,
 		service:  gameServer,
 		log:      log,
 		addr:     lis.Addr().String(),
+		creds:    creds,
 	}, nil
 }
 
+func (ts *TestServer) Addr() string {
+	return ts.addr
+}
+
+// URL returns the URL of the server for gRPC-Web and WebSocket clients.
+func (ts *TestServer) URL() string {
+	return "http://" + ts.addr
+}
+
 func (ts *TestServer) NewPlayer(ctx context.Context, player *gamesvc.Player) (*TestPlayer, error) {
 	token := uuid.NewString()
 	err := ts.playerDB.SetPlayer(ctx, token, player)
@@ -67,7 +177,7 @@ func (ts *TestServer) NewPlayer(ctx context.Context, player *gamesvc.Player) (*T
 		return nil, fmt.Errorf("set player: %w", err)
 	}
 
-	conn, err := grpc.DialContext(ctx, ts.addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := grpc.DialContext(ctx, ts.addr, grpc.WithTransportCredentials(ts.creds))
 	if err != nil {
 		return nil, fmt.Errorf("dial: %w", err)
 	}
@@ -81,6 +191,22 @@ func (ts *TestServer) NewPlayer(ctx context.Context, player *gamesvc.Player) (*T
 	return newTestPlayer(client, player, token, log), nil
 }
 
+// AdminClient returns a client of the admin service. Requests are
+// authorized with AdminContext.
+func (ts *TestServer) AdminClient(ctx context.Context) (adminsvc.AdminServiceClient, error) {
+	conn, err := grpc.DialContext(ctx, ts.addr, grpc.WithTransportCredentials(ts.creds))
+	if err != nil {
+		return nil, fmt.Errorf("dial: %w", err)
+	}
+
+	return adminsvc.NewAdminServiceClient(conn), nil
+}
+
+// AdminContext returns ctx that authorizes requests to the admin service.
+func AdminContext(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, mdkey.AdminAuth, AdminToken)
+}
+
 func (ts *TestServer) JoinPlayers(ctx context.Context, roomID string, players ...*TestPlayer) []*TestPlayerInRoom {
 	inRoom := make([]*TestPlayerInRoom, 0, len(players))
 	for _, player := range players {
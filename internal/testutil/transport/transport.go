@@ -0,0 +1,57 @@
+// Package transport has an in-memory transport of a player, so tests decide
+// how fast the player reads.
+package transport
+
+import (
+	"context"
+	"io"
+
+	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	"github.com/knightpp/alias-server/internal/game/entity"
+)
+
+var _ entity.Transport = (*Memory)(nil)
+
+// Memory passes messages through unbuffered channels. Send blocks until the
+// message is taken from Out, Recv returns messages put into In and io.EOF
+// once In is closed.
+type Memory struct {
+	In  chan *gamesvc.Message
+	Out chan *gamesvc.Message
+
+	ctx context.Context
+}
+
+// NewMemory returns a transport that is connected until ctx is done.
+func NewMemory(ctx context.Context) *Memory {
+	return &Memory{
+		In:  make(chan *gamesvc.Message),
+		Out: make(chan *gamesvc.Message),
+		ctx: ctx,
+	}
+}
+
+func (m *Memory) Context() context.Context {
+	return m.ctx
+}
+
+func (m *Memory) Send(msg *gamesvc.Message) error {
+	select {
+	case m.Out <- msg:
+		return nil
+	case <-m.ctx.Done():
+		return m.ctx.Err()
+	}
+}
+
+func (m *Memory) Recv() (*gamesvc.Message, error) {
+	select {
+	case msg, ok := <-m.In:
+		if !ok {
+			return nil, io.EOF
+		}
+		return msg, nil
+	case <-m.ctx.Done():
+		return nil, m.ctx.Err()
+	}
+}
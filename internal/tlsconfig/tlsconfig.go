@@ -0,0 +1,152 @@
+// Package tlsconfig serves TLS with a certificate that is reloaded when its
+// files change, and optionally verifies client certificates.
+package tlsconfig
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+type Options struct {
+	CertFile string
+	KeyFile  string
+	// ClientCAFile enables verification of client certificates. They are
+	// optional for connections, services decide whether to require them.
+	ClientCAFile string
+	// ReloadInterval is how often files of the certificate are checked for
+	// changes.
+	ReloadInterval time.Duration
+}
+
+func DefaultOptions(certFile, keyFile string) Options {
+	return Options{
+		CertFile:       certFile,
+		KeyFile:        keyFile,
+		ReloadInterval: 10 * time.Second,
+	}
+}
+
+// Reloader keeps the certificate up to date with its files.
+type Reloader struct {
+	log  zerolog.Logger
+	opts Options
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	certPEM []byte
+	keyPEM  []byte
+}
+
+// NewReloader loads the certificate, it fails if files are invalid.
+func NewReloader(log zerolog.Logger, opts Options) (*Reloader, error) {
+	r := &Reloader{
+		log:  log,
+		opts: opts,
+	}
+
+	_, err := r.Reload()
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Start reloads the certificate until ctx is done. The previous certificate
+// is kept if files are invalid, e.g. when only one of them was replaced.
+func (r *Reloader) Start(ctx context.Context) {
+	ticker := time.NewTicker(r.opts.ReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		reloaded, err := r.Reload()
+		switch {
+		case err != nil:
+			r.log.Err(err).Msg("could not reload TLS certificate")
+		case reloaded:
+			r.log.Info().Msg("reloaded TLS certificate")
+		}
+	}
+}
+
+// Reload loads the certificate if its files have changed.
+func (r *Reloader) Reload() (bool, error) {
+	certPEM, err := os.ReadFile(r.opts.CertFile)
+	if err != nil {
+		return false, fmt.Errorf("read certificate: %w", err)
+	}
+
+	keyPEM, err := os.ReadFile(r.opts.KeyFile)
+	if err != nil {
+		return false, fmt.Errorf("read key: %w", err)
+	}
+
+	r.mu.RLock()
+	changed := !bytes.Equal(certPEM, r.certPEM) || !bytes.Equal(keyPEM, r.keyPEM)
+	r.mu.RUnlock()
+	if !changed {
+		return false, nil
+	}
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return false, fmt.Errorf("parse key pair: %w", err)
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.certPEM = certPEM
+	r.keyPEM = keyPEM
+	r.mu.Unlock()
+
+	return true, nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate.
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.cert, nil
+}
+
+// Config returns config of the server with the certificate of r.
+func (r *Reloader) Config() (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		NextProtos:     []string{"h2"},
+		GetCertificate: r.GetCertificate,
+	}
+
+	if r.opts.ClientCAFile != "" {
+		caPEM, err := os.ReadFile(r.opts.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read client CA: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, errors.New("client CA has no certificates")
+		}
+
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	return cfg, nil
+}
@@ -10,3 +10,15 @@ func NewT2[A, B any](a A, b B) T2[A, B] {
 		A: a, B: b,
 	}
 }
+
+type T3[A any, B any, C any] struct {
+	A A
+	B B
+	C C
+}
+
+func NewT3[A, B, C any](a A, b B, c C) T3[A, B, C] {
+	return T3[A, B, C]{
+		A: a, B: b, C: c,
+	}
+}
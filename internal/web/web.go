@@ -0,0 +1,67 @@
+// Package web serves gRPC services to browsers over gRPC-Web and its
+// websocket transport, which also supports the bidirectional Join stream.
+package web
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/improbable-eng/grpc-web/go/grpcweb"
+	"github.com/knightpp/alias-proto/go/mdkey"
+	"github.com/knightpp/alias-server/internal/logging"
+	"google.golang.org/grpc"
+)
+
+type Options struct {
+	// AllowedOrigins of cross-origin requests, "*" allows any origin.
+	// Requests from the same origin are always allowed.
+	AllowedOrigins []string
+}
+
+// ParseOrigins parses origins written as "https://a.com,https://b.com".
+func ParseOrigins(s string) []string {
+	var origins []string
+	for _, origin := range strings.Split(s, ",") {
+		origin = strings.TrimSpace(origin)
+		if origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
+
+// Handler serves gRPC-Web requests by s and passes other requests to s.
+func Handler(s *grpc.Server, opts Options) http.Handler {
+	allowed := func(origin string) bool {
+		for _, o := range opts.AllowedOrigins {
+			if o == "*" || o == origin {
+				return true
+			}
+		}
+		return false
+	}
+
+	return grpcweb.WrapServer(s,
+		grpcweb.WithOriginFunc(allowed),
+		grpcweb.WithAllowedRequestHeaders([]string{
+			// sent by gRPC-Web clients
+			"content-type",
+			"x-grpc-web",
+			"x-user-agent",
+			"grpc-timeout",
+
+			mdkey.Auth,
+			mdkey.RoomID,
+			mdkey.AdminAuth,
+			logging.RequestIDKey,
+		}),
+		grpcweb.WithWebsockets(true),
+		grpcweb.WithWebsocketOriginFunc(func(req *http.Request) bool {
+			origin, err := grpcweb.WebsocketRequestOrigin(req)
+			if err != nil {
+				return false
+			}
+			return origin == req.Host || allowed(req.Header.Get("Origin"))
+		}),
+	)
+}
@@ -0,0 +1,283 @@
+// Package wsgateway lets clients without gRPC join rooms over WebSocket.
+// Messages are text frames with gamesvc.Message encoded as protojson.
+package wsgateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	"github.com/knightpp/alias-proto/go/mdkey"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"nhooyr.io/websocket"
+)
+
+const (
+	// CloseCodeOffset is added to the gRPC status code of the Join stream
+	// to get the close code of the WebSocket, e.g. 4016 is Unauthenticated.
+	CloseCodeOffset = 4000
+
+	// Subprotocol is the WebSocket subprotocol a client must offer. Browsers
+	// can't set headers of WebSockets, so the auth token is offered as
+	// another subprotocol prefixed with AuthSubprotocolPrefix, e.g.
+	// new WebSocket(url, ["alias.v1", "auth." + token]). Unlike query
+	// parameters, subprotocols don't end up in access logs.
+	Subprotocol           = "alias.v1"
+	AuthSubprotocolPrefix = "auth."
+)
+
+var joinInfo = &grpc.StreamServerInfo{
+	FullMethod:     "/" + gamesvc.GameService_ServiceDesc.ServiceName + "/Join",
+	IsClientStream: true,
+	IsServerStream: true,
+}
+
+// JoinFunc handles the Join stream, e.g. server.GameService.Join.
+type JoinFunc func(gamesvc.GameService_JoinServer) error
+
+type Options struct {
+	// AllowedOrigins of cross-origin connections, "*" allows any origin.
+	// Connections from the same origin are always allowed.
+	AllowedOrigins []string
+	// ReadLimit is the max size of a message in bytes.
+	ReadLimit int64
+	// Interceptors run around Join in the same order as interceptors of the
+	// gRPC server, e.g. recovery, rate limits and logging.
+	Interceptors []grpc.StreamServerInterceptor
+}
+
+func DefaultOptions() Options {
+	return Options{
+		ReadLimit: 32 << 10,
+	}
+}
+
+type Gateway struct {
+	log  zerolog.Logger
+	join JoinFunc
+	opts Options
+}
+
+func New(log zerolog.Logger, join JoinFunc, opts Options) *Gateway {
+	return &Gateway{
+		log:  log,
+		join: join,
+		opts: opts,
+	}
+}
+
+// ServeHTTP accepts the WebSocket and joins the room. Metadata of Join is
+// taken from headers, otherwise the auth token is taken from subprotocols
+// and ID of the room from the query parameter with the same name.
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	md := metadata.MD{}
+	if token := authToken(r); token != "" {
+		md.Set(mdkey.Auth, token)
+	}
+	if roomID := r.Header.Get(mdkey.RoomID); roomID != "" {
+		md.Set(mdkey.RoomID, roomID)
+	} else if roomID := r.URL.Query().Get(mdkey.RoomID); roomID != "" {
+		md.Set(mdkey.RoomID, roomID)
+	}
+
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+		Subprotocols:   []string{Subprotocol},
+		OriginPatterns: originPatterns(g.opts.AllowedOrigins),
+	})
+	if err != nil {
+		// Accept has already written the response
+		g.log.Debug().Err(err).Msg("could not accept websocket")
+		return
+	}
+	defer conn.CloseNow()
+
+	conn.SetReadLimit(g.opts.ReadLimit)
+
+	ctx := metadata.NewIncomingContext(r.Context(), md)
+	if addr, err := net.ResolveTCPAddr("tcp", r.RemoteAddr); err == nil {
+		ctx = peer.NewContext(ctx, &peer.Peer{Addr: addr})
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	err = g.serveJoin(&stream{ctx: ctx, conn: conn})
+	if err == nil {
+		_ = conn.Close(websocket.StatusNormalClosure, "")
+		return
+	}
+
+	st := status.Convert(err)
+	reason := st.Message()
+	// reason must fit into a control frame
+	if len(reason) > 123 {
+		reason = reason[:123]
+	}
+	_ = conn.Close(websocket.StatusCode(CloseCodeOffset+int(st.Code())), reason)
+}
+
+// serveJoin runs Join through the interceptors.
+func (g *Gateway) serveJoin(s *stream) error {
+	handler := func(_ any, ss grpc.ServerStream) error {
+		return g.join(joinServer{ServerStream: ss})
+	}
+	for i := len(g.opts.Interceptors) - 1; i >= 0; i-- {
+		interceptor, next := g.opts.Interceptors[i], handler
+		handler = func(srv any, ss grpc.ServerStream) error {
+			return interceptor(srv, ss, joinInfo, next)
+		}
+	}
+
+	return handler(nil, s)
+}
+
+// authToken returns the auth token from the header or from subprotocols.
+func authToken(r *http.Request) string {
+	if token := r.Header.Get(mdkey.Auth); token != "" {
+		return token
+	}
+
+	for _, header := range r.Header.Values("Sec-WebSocket-Protocol") {
+		for _, protocol := range strings.Split(header, ",") {
+			token, ok := strings.CutPrefix(strings.TrimSpace(protocol), AuthSubprotocolPrefix)
+			if ok {
+				return token
+			}
+		}
+	}
+	return ""
+}
+
+// originPatterns converts origins to hosts that websocket matches.
+func originPatterns(origins []string) []string {
+	patterns := make([]string, 0, len(origins))
+	for _, origin := range origins {
+		if origin == "*" {
+			patterns = append(patterns, origin)
+			continue
+		}
+
+		u, err := url.Parse(origin)
+		if err != nil || u.Host == "" {
+			continue
+		}
+		patterns = append(patterns, u.Host)
+	}
+	return patterns
+}
+
+var _ gamesvc.GameService_JoinServer = (*stream)(nil)
+
+// joinServer adapts a stream wrapped by interceptors to the Join stream.
+type joinServer struct {
+	grpc.ServerStream
+}
+
+func (s joinServer) Send(msg *gamesvc.Message) error {
+	return s.SendMsg(msg)
+}
+
+func (s joinServer) Recv() (*gamesvc.Message, error) {
+	msg := &gamesvc.Message{}
+	err := s.RecvMsg(msg)
+	if err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// stream adapts a WebSocket to the Join stream.
+type stream struct {
+	ctx  context.Context
+	conn *websocket.Conn
+}
+
+func (s *stream) Send(msg *gamesvc.Message) error {
+	data, err := protojson.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("marshal message: %w", err)
+	}
+
+	return s.conn.Write(s.ctx, websocket.MessageText, data)
+}
+
+// Recv returns io.EOF when the client closes the WebSocket. A malformed
+// message is answered with an error and skipped.
+func (s *stream) Recv() (*gamesvc.Message, error) {
+	for {
+		typ, data, err := s.conn.Read(s.ctx)
+		switch {
+		case websocket.CloseStatus(err) == websocket.StatusNormalClosure,
+			websocket.CloseStatus(err) == websocket.StatusGoingAway:
+			return nil, io.EOF
+		case errors.Is(err, context.Canceled):
+			return nil, status.FromContextError(err).Err()
+		case err != nil:
+			return nil, err
+		}
+
+		if typ != websocket.MessageText {
+			err = errors.New("message must be a text frame")
+		} else {
+			msg := &gamesvc.Message{}
+			err = protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, msg)
+			if err == nil {
+				return msg, nil
+			}
+		}
+
+		err = s.Send(&gamesvc.Message{
+			Message: &gamesvc.Message_Error{
+				Error: &gamesvc.MsgError{
+					Error: fmt.Sprintf("invalid message: %s", err),
+				},
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (s *stream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *stream) SetHeader(metadata.MD) error  { return nil }
+func (s *stream) SendHeader(metadata.MD) error { return nil }
+func (s *stream) SetTrailer(metadata.MD)       {}
+
+func (s *stream) SendMsg(m any) error {
+	msg, ok := m.(*gamesvc.Message)
+	if !ok {
+		return fmt.Errorf("unexpected message %T", m)
+	}
+	return s.Send(msg)
+}
+
+func (s *stream) RecvMsg(m any) error {
+	msg, ok := m.(*gamesvc.Message)
+	if !ok {
+		return fmt.Errorf("unexpected message %T", m)
+	}
+
+	received, err := s.Recv()
+	if err != nil {
+		return err
+	}
+
+	proto.Reset(msg)
+	proto.Merge(msg, received)
+	return nil
+}
@@ -0,0 +1,163 @@
+package socket_test
+
+import (
+	"time"
+
+	adminsvc "github.com/knightpp/alias-proto/go/admin_service"
+	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	"github.com/knightpp/alias-proto/go/mdkey"
+	"github.com/knightpp/alias-server/internal/testutil/matcher"
+	"github.com/knightpp/alias-server/internal/testutil/testserver"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var _ = Describe("Admin", func() {
+	var (
+		admin        adminsvc.AdminServiceClient
+		roomID       string
+		conn1, conn2 *testserver.TestPlayerInRoom
+	)
+
+	BeforeEach(func(ctx SpecContext) {
+		srv, err := testserver.CreateAndStart()
+		Expect(err).ShouldNot(HaveOccurred())
+
+		admin, err = srv.AdminClient(ctx)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		players := srv.CreatePlayers(ctx, 2, protoPlayer)
+
+		roomID, err = players[0].CreateRoom(ctx, protoRoom())
+		Expect(err).ShouldNot(HaveOccurred())
+
+		conns := srv.JoinPlayers(ctx, roomID, players...)
+		conn1, conn2 = conns[0], conns[1]
+	}, NodeTimeout(time.Second))
+
+	noticeMsg := func(text string) *gamesvc.Message {
+		return &gamesvc.Message{
+			Message: &gamesvc.Message_ServerNotice{
+				ServerNotice: &gamesvc.MsgServerNotice{
+					Text: text,
+				},
+			},
+		}
+	}
+
+	It("requires the admin token", func(ctx SpecContext) {
+		_, err := admin.ListRooms(ctx, &adminsvc.ListRoomsRequest{})
+		Expect(status.Code(err)).Should(Equal(codes.Unauthenticated))
+	}, NodeTimeout(time.Second))
+
+	It("rejects a wrong admin token", func(ctx SpecContext) {
+		wrongCtx := metadata.AppendToOutgoingContext(ctx, mdkey.AdminAuth, "wrong")
+
+		_, err := admin.ListRooms(wrongCtx, &adminsvc.ListRoomsRequest{})
+		Expect(status.Code(err)).Should(Equal(codes.PermissionDenied))
+	}, NodeTimeout(time.Second))
+
+	It("lists rooms with their state", func(ctx SpecContext) {
+		startTeamGame(ctx, "team", conn1, conn2)
+		startTurn(ctx, conn1, conn2)
+
+		resp, err := admin.ListRooms(testserver.AdminContext(ctx), &adminsvc.ListRoomsRequest{})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(resp.Rooms).Should(HaveLen(1))
+
+		room := resp.Rooms[0]
+		Expect(room.Room.Id).Should(Equal(roomID))
+		Expect(room.State).Should(Equal("Turn"))
+		Expect(room.PlayerIdTurn).Should(Equal(conn1.ID()))
+		Expect(room.TurnDeadlineMs).Should(BeNumerically(">", time.Now().UnixMilli()))
+
+		err = conn1.EndTurn(3, 1)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(conn2.NextMsg(ctx).GetEndTurn()).ShouldNot(BeNil())
+
+		resp, err = admin.ListRooms(testserver.AdminContext(ctx), &adminsvc.ListRoomsRequest{})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(resp.Rooms).Should(HaveLen(1))
+
+		room = resp.Rooms[0]
+		Expect(room.State).Should(Equal("Game"))
+		Expect(room.TurnDeadlineMs).Should(BeZero())
+		Expect(room.TeamIdToStats).Should(HaveLen(1))
+		for _, stats := range room.TeamIdToStats {
+			Expect(stats.Rights).Should(BeEquivalentTo(3))
+			Expect(stats.Wrongs).Should(BeEquivalentTo(1))
+		}
+	}, NodeTimeout(time.Second))
+
+	It("closes a room", func(ctx SpecContext) {
+		_, err := admin.CloseRoom(testserver.AdminContext(ctx), &adminsvc.CloseRoomRequest{
+			RoomId: roomID,
+			Reason: "maintenance",
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		each(func(conn *testserver.TestPlayerInRoom) {
+			Expect(conn.NextMsg(ctx)).Should(matcher.EqualCmp(noticeMsg("maintenance")))
+			Eventually(ctx, conn.Closed()).Should(BeClosed())
+		}, conn1, conn2)
+
+		Eventually(func() []*gamesvc.Room {
+			resp, err := conn1.Client().ListRooms(ctx, &gamesvc.ListRoomsRequest{})
+			Expect(err).ShouldNot(HaveOccurred())
+			return resp.Rooms
+		}).Should(BeEmpty())
+	}, NodeTimeout(time.Second))
+
+	It("kicks a player", func(ctx SpecContext) {
+		_, err := admin.KickPlayer(testserver.AdminContext(ctx), &adminsvc.KickPlayerRequest{
+			RoomId:   roomID,
+			PlayerId: conn2.ID(),
+			Reason:   "be nice",
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		Expect(conn2.NextMsg(ctx)).Should(matcher.EqualCmp(noticeMsg("be nice")))
+		Eventually(ctx, conn2.Closed()).Should(BeClosed())
+
+		update := conn1.NextMsg(ctx).GetUpdateRoom()
+		Expect(update.GetRoom().GetLobby()).Should(HaveLen(1))
+		Expect(update.GetRoom().GetLobby()[0].Id).Should(Equal(conn1.ID()))
+	}, NodeTimeout(time.Second))
+
+	It("doesn't kick a player who is not in the room", func(ctx SpecContext) {
+		_, err := admin.KickPlayer(testserver.AdminContext(ctx), &adminsvc.KickPlayerRequest{
+			RoomId:   roomID,
+			PlayerId: "unknown",
+		})
+		Expect(status.Code(err)).Should(Equal(codes.NotFound))
+	}, NodeTimeout(time.Second))
+
+	It("broadcasts a notice", func(ctx SpecContext) {
+		resp, err := admin.BroadcastNotice(testserver.AdminContext(ctx), &adminsvc.BroadcastNoticeRequest{
+			Text: "server restarts in 5 minutes",
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(resp.Players).Should(BeEquivalentTo(2))
+
+		each(func(conn *testserver.TestPlayerInRoom) {
+			Expect(conn.NextMsg(ctx)).Should(matcher.EqualCmp(noticeMsg("server restarts in 5 minutes")))
+		}, conn1, conn2)
+	}, NodeTimeout(time.Second))
+
+	It("looks up a player by ID", func(ctx SpecContext) {
+		resp, err := admin.GetPlayer(testserver.AdminContext(ctx), &adminsvc.GetPlayerRequest{
+			Key: &adminsvc.GetPlayerRequest_PlayerId{PlayerId: conn1.ID()},
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(resp.Player).Should(matcher.EqualCmp(conn1.Proto()))
+		Expect(resp.GetRoomId()).Should(Equal(roomID))
+
+		_, err = admin.GetPlayer(testserver.AdminContext(ctx), &adminsvc.GetPlayerRequest{
+			Key: &adminsvc.GetPlayerRequest_PlayerId{PlayerId: "unknown"},
+		})
+		Expect(status.Code(err)).Should(Equal(codes.NotFound))
+	}, NodeTimeout(time.Second))
+})
@@ -0,0 +1,90 @@
+package socket_test
+
+import (
+	"strings"
+	"time"
+
+	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	"github.com/knightpp/alias-server/internal/testutil/matcher"
+	"github.com/knightpp/alias-server/internal/testutil/testserver"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Chat", func() {
+	var conn1, conn2 *testserver.TestPlayerInRoom
+
+	BeforeEach(func(ctx SpecContext) {
+		conn1, conn2 = createTwoPlayers(ctx)
+	}, NodeTimeout(time.Second))
+
+	chatMsg := func(conn *testserver.TestPlayerInRoom, text string) *gamesvc.Message {
+		return &gamesvc.Message{
+			Message: &gamesvc.Message_Chat{
+				Chat: &gamesvc.MsgChat{
+					PlayerId: conn.ID(),
+					Text:     text,
+				},
+			},
+		}
+	}
+
+	It("broadcasts a message to the room", func(ctx SpecContext) {
+		err := conn1.Chat("  hello  ")
+		Expect(err).ShouldNot(HaveOccurred())
+
+		each(func(conn *testserver.TestPlayerInRoom) {
+			Expect(conn.NextMsg(ctx)).Should(matcher.EqualCmp(chatMsg(conn1, "hello")))
+		}, conn1, conn2)
+	}, NodeTimeout(time.Second))
+
+	It("rejects a long message", func(ctx SpecContext) {
+		err := conn1.Chat(strings.Repeat("a", 301))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		Expect(conn1.NextMsg(ctx).GetError()).ShouldNot(BeNil())
+	}, NodeTimeout(time.Second))
+
+	It("limits how often a player can send messages", func(ctx SpecContext) {
+		for i := 0; i < 6; i++ {
+			err := conn1.Chat("spam")
+			Expect(err).ShouldNot(HaveOccurred())
+		}
+
+		for i := 0; i < 5; i++ {
+			Expect(conn1.NextMsg(ctx)).Should(matcher.EqualCmp(chatMsg(conn1, "spam")))
+			Expect(conn2.NextMsg(ctx)).Should(matcher.EqualCmp(chatMsg(conn1, "spam")))
+		}
+		Expect(conn1.NextMsg(ctx).GetError().GetError()).Should(Equal("too many chat messages"))
+	}, NodeTimeout(time.Second))
+
+	When("in a turn", func() {
+		BeforeEach(func(ctx SpecContext) {
+			startTeamGame(ctx, "team", conn1, conn2)
+			startTurn(ctx, conn1, conn2)
+
+			err := conn1.Word("apple")
+			Expect(err).ShouldNot(HaveOccurred())
+		}, NodeTimeout(time.Second))
+
+		It("stops the explainer from typing the word", func(ctx SpecContext) {
+			err := conn1.Chat("it is an Apple!")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(conn1.NextMsg(ctx).GetError()).ShouldNot(BeNil())
+
+			err = conn1.Chat("a fruit")
+			Expect(err).ShouldNot(HaveOccurred())
+			each(func(conn *testserver.TestPlayerInRoom) {
+				Expect(conn.NextMsg(ctx)).Should(matcher.EqualCmp(chatMsg(conn1, "a fruit")))
+			}, conn1, conn2)
+		}, NodeTimeout(time.Second))
+
+		It("lets the guesser type the word", func(ctx SpecContext) {
+			err := conn2.Chat("apple")
+			Expect(err).ShouldNot(HaveOccurred())
+			each(func(conn *testserver.TestPlayerInRoom) {
+				Expect(conn.NextMsg(ctx)).Should(matcher.EqualCmp(chatMsg(conn2, "apple")))
+			}, conn1, conn2)
+		}, NodeTimeout(time.Second))
+	})
+})
@@ -0,0 +1,76 @@
+package socket_test
+
+import (
+	"time"
+
+	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	"github.com/knightpp/alias-server/internal/testutil/matcher"
+	"github.com/knightpp/alias-server/internal/testutil/testserver"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cheat detection", func() {
+	var (
+		conn1, conn2 *testserver.TestPlayerInRoom
+		teamID       string
+	)
+
+	BeforeEach(func(ctx SpecContext) {
+		conn1, conn2 = createTwoPlayers(ctx)
+		teamID = startTeamGame(ctx, "team", conn1, conn2)
+		startTurn(ctx, conn1, conn2)
+
+		err := conn1.Word("яблуко")
+		Expect(err).ShouldNot(HaveOccurred())
+	}, NodeTimeout(time.Second))
+
+	It("blocks the word given away by the explainer", func(ctx SpecContext) {
+		for _, text := range []string{
+			"ЯБЛУКО!",
+			"червоні яблука",
+			"yabluka",
+			"я б л у к о",
+		} {
+			err := conn1.Chat(text)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Expect(conn1.NextMsg(ctx).GetError().GetError()).Should(
+				Equal("explainer cannot give away the word, penalty applied"),
+				"text: %s", text,
+			)
+		}
+	}, NodeTimeout(time.Second))
+
+	It("lets the explainer describe the word", func(ctx SpecContext) {
+		err := conn1.Chat("червоний фрукт")
+		Expect(err).ShouldNot(HaveOccurred())
+
+		each(func(conn *testserver.TestPlayerInRoom) {
+			Expect(conn.NextMsg(ctx).GetChat().GetText()).Should(Equal("червоний фрукт"))
+		}, conn1, conn2)
+	}, NodeTimeout(time.Second))
+
+	It("records a penalty in the results", func(ctx SpecContext) {
+		for _, text := range []string{"яблуко", "yabluko"} {
+			err := conn1.Chat(text)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(conn1.NextMsg(ctx).GetError()).ShouldNot(BeNil())
+		}
+
+		err := conn1.EndTurn(3, 1)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(conn2.NextMsg(ctx).GetEndTurn()).ShouldNot(BeNil())
+
+		err = conn1.EndGame()
+		Expect(err).ShouldNot(HaveOccurred())
+
+		each(func(conn *testserver.TestPlayerInRoom) {
+			Expect(conn.NextMsg(ctx).GetResults()).Should(matcher.EqualCmp(&gamesvc.MsgResults{
+				TeamIdToStats: map[string]*gamesvc.Statistics{
+					teamID: {Rights: 3, Wrongs: 1, Penalties: 2},
+				},
+			}))
+		}, conn1, conn2)
+	}, NodeTimeout(time.Second))
+})
@@ -12,6 +12,9 @@ import (
 
 var _ = Describe("Four players", func() {
 	var (
+		srv         *testserver.TestServer
+		roomID      string
+		player1     *testserver.TestPlayer
 		conn1       *testserver.TestPlayerInRoom
 		conn2       *testserver.TestPlayerInRoom
 		conn3       *testserver.TestPlayerInRoom
@@ -26,15 +29,16 @@ var _ = Describe("Four players", func() {
 		)
 
 		By("create test server")
-		srv, err := testserver.CreateAndStart()
+		var err error
+		srv, err = testserver.CreateAndStart()
 		Expect(err).ShouldNot(HaveOccurred())
 
 		By("create fist player")
-		player1, err := srv.NewPlayer(ctx, protoPlayer(1))
+		player1, err = srv.NewPlayer(ctx, protoPlayer(1))
 		Expect(err).ShouldNot(HaveOccurred())
 
 		By("create room")
-		roomID, err := player1.CreateRoom(ctx, protoRoom())
+		roomID, err = player1.CreateRoom(ctx, protoRoom())
 		Expect(err).ShouldNot(HaveOccurred())
 
 		By("create other players")
@@ -121,6 +125,48 @@ var _ = Describe("Four players", func() {
 
 		})
 
+		It("lists the room as in game", func(ctx SpecContext) {
+			resp, err := conn1.Client().ListRooms(ctx, &gamesvc.ListRoomsRequest{
+				Statuses: []gamesvc.RoomStatus{gamesvc.RoomStatus_ROOM_STATUS_IN_GAME},
+			})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(resp.Rooms).Should(HaveLen(1))
+			Expect(resp.Rooms[0].Status).Should(Equal(gamesvc.RoomStatus_ROOM_STATUS_IN_GAME))
+			Expect(resp.Rooms[0].PlayerCount).Should(BeEquivalentTo(4))
+			Expect(resp.Rooms[0].Capacity).Should(BeEquivalentTo(4))
+		}, NodeTimeout(time.Second))
+
+		It("sends a snapshot on request", func(ctx SpecContext) {
+			err := conn2.RequestSnapshot()
+			Expect(err).ShouldNot(HaveOccurred())
+
+			snapshot := conn2.NextMsg(ctx).GetSnapshot()
+			Expect(snapshot.GetState()).Should(Equal("Game"))
+			Expect(snapshot.GetPlayerIdTurn()).Should(Equal(conn1.ID()))
+			Expect(snapshot.GetTurnDeadlineUnixMs()).Should(BeZero())
+			Expect(snapshot.GetRoom().GetTeams()).Should(HaveLen(2))
+		}, NodeTimeout(time.Second))
+
+		It("rejects turns while paused", func(ctx SpecContext) {
+			err := conn1.Pause()
+			Expect(err).ShouldNot(HaveOccurred())
+			each(func(conn *testserver.TestPlayerInRoom) {
+				Expect(conn.NextMsg(ctx).GetPause()).Should(matcher.EqualCmp(&gamesvc.MsgPause{
+					PlayerId: conn1.ID(),
+				}))
+			}, conn1, conn2, conn3, conn4)
+
+			err = conn1.StartTurn(time.Minute)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(conn1.NextMsg(ctx).GetError().GetError()).Should(Equal("game is paused"))
+
+			err = conn1.EndGame()
+			Expect(err).ShouldNot(HaveOccurred())
+			each(func(conn *testserver.TestPlayerInRoom) {
+				Expect(conn.NextMsg(ctx).GetResults()).ShouldNot(BeNil())
+			}, conn1, conn2, conn3, conn4)
+		}, NodeTimeout(time.Second))
+
 		It("when game is not started sending word should error", func(ctx SpecContext) {
 			err := conn1.Word("word")
 
@@ -139,6 +185,137 @@ var _ = Describe("Four players", func() {
 				}, conn1, conn2, conn3, conn4)
 			}, NodeTimeout(time.Second))
 
+			It("lists the room in turn", func(ctx SpecContext) {
+				resp, err := conn1.Client().ListRooms(ctx, &gamesvc.ListRoomsRequest{})
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(resp.Rooms).Should(HaveLen(1))
+				Expect(resp.Rooms[0].Status).Should(Equal(gamesvc.RoomStatus_ROOM_STATUS_TURN))
+			}, NodeTimeout(time.Second))
+
+			It("sends a snapshot to a late player", func(ctx SpecContext) {
+				player5, err := srv.NewPlayer(ctx, protoPlayer(5))
+				Expect(err).ShouldNot(HaveOccurred())
+
+				late, err := player5.Join(roomID)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				Expect(late.NextMsg(ctx).GetUpdateRoom().GetRoom().GetLobby()).Should(HaveLen(1))
+
+				snapshot := late.NextMsg(ctx).GetSnapshot()
+				Expect(snapshot.GetState()).Should(Equal("Turn"))
+				Expect(snapshot.GetPlayerIdTurn()).Should(Equal(conn1.ID()))
+				Expect(time.UnixMilli(snapshot.GetTurnDeadlineUnixMs())).Should(BeTemporally("~", time.Now().Add(time.Minute), 5*time.Second))
+				Expect(snapshot.GetRoom().GetTeams()).Should(HaveLen(2))
+			}, NodeTimeout(time.Second))
+
+			It("pauses and resumes the turn", func(ctx SpecContext) {
+				err := conn2.Pause()
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(conn2.NextMsg(ctx).GetError()).ShouldNot(BeNil())
+
+				err = conn1.Pause()
+				Expect(err).ShouldNot(HaveOccurred())
+				var remaining uint64
+				each(func(conn *testserver.TestPlayerInRoom) {
+					pause := conn.NextMsg(ctx).GetPause()
+					Expect(pause.GetPlayerId()).Should(Equal(conn1.ID()))
+					Expect(pause.GetRemainingTurnMs()).Should(BeNumerically("~", time.Minute.Milliseconds(), 5000))
+					remaining = pause.GetRemainingTurnMs()
+				}, conn1, conn2, conn3, conn4)
+
+				err = conn1.Word("abc")
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(conn1.NextMsg(ctx).GetError().GetError()).Should(Equal("game is paused"))
+
+				err = conn1.Resume()
+				Expect(err).ShouldNot(HaveOccurred())
+				each(func(conn *testserver.TestPlayerInRoom) {
+					Expect(conn.NextMsg(ctx).GetResume()).Should(matcher.EqualCmp(&gamesvc.MsgResume{
+						PlayerId:        conn1.ID(),
+						RemainingTurnMs: remaining,
+					}))
+				}, conn1, conn2, conn3, conn4)
+
+				err = conn1.Word("abc")
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(conn3.NextMsg(ctx).GetWord().GetWord()).Should(Equal("abc"))
+			}, NodeTimeout(time.Second))
+
+			It("ends the game paused during the turn", func(ctx SpecContext) {
+				err := conn1.Pause()
+				Expect(err).ShouldNot(HaveOccurred())
+				each(func(conn *testserver.TestPlayerInRoom) {
+					Expect(conn.NextMsg(ctx).GetPause()).ShouldNot(BeNil())
+				}, conn1, conn2, conn3, conn4)
+
+				err = conn2.React("😀")
+				Expect(err).ShouldNot(HaveOccurred())
+				each(func(conn *testserver.TestPlayerInRoom) {
+					Expect(conn.NextMsg(ctx).GetReaction().GetEmoji()).Should(Equal("😀"))
+				}, conn1, conn2, conn3, conn4)
+
+				err = conn1.EndGame()
+				Expect(err).ShouldNot(HaveOccurred())
+				each(func(conn *testserver.TestPlayerInRoom) {
+					Expect(conn.NextMsg(ctx).GetResults()).ShouldNot(BeNil())
+				}, conn1, conn2, conn3, conn4)
+			}, NodeTimeout(time.Second))
+
+			It("pauses when the explainer leaves", func(ctx SpecContext) {
+				conn1.Cancel()
+
+				each(func(conn *testserver.TestPlayerInRoom) {
+					Expect(conn.NextMsg(ctx).GetUpdateRoom()).ShouldNot(BeNil())
+					Expect(conn.NextMsg(ctx).GetPause().GetPlayerId()).Should(Equal(conn1.ID()))
+				}, conn2, conn3, conn4)
+
+				resp, err := conn2.Client().ListRooms(ctx, &gamesvc.ListRoomsRequest{})
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(resp.Rooms).Should(HaveLen(1))
+				Expect(resp.Rooms[0].Status).Should(Equal(gamesvc.RoomStatus_ROOM_STATUS_PAUSED))
+			}, NodeTimeout(time.Second))
+
+			It("resumes the turn when the explainer is back", func(ctx SpecContext) {
+				By("explainer leaves")
+				conn1.Cancel()
+				each(func(conn *testserver.TestPlayerInRoom) {
+					room := conn.NextMsg(ctx).GetUpdateRoom().GetRoom()
+					Expect(room.GetLeaderId()).Should(Equal(conn2.ID()))
+					Expect(room.GetTeams()[0].GetPlayerA()).Should(BeNil())
+					Expect(conn.NextMsg(ctx).GetPause()).ShouldNot(BeNil())
+				}, conn2, conn3, conn4)
+
+				By("explainer joins again")
+				rejoined, err := player1.Join(roomID)
+				Expect(err).ShouldNot(HaveOccurred())
+				each(func(conn *testserver.TestPlayerInRoom) {
+					room := conn.NextMsg(ctx).GetUpdateRoom().GetRoom()
+					Expect(room.GetTeams()[0].GetPlayerA().GetId()).Should(Equal(conn1.ID()))
+					Expect(room.GetLobby()).Should(BeEmpty())
+				}, rejoined, conn2, conn3, conn4)
+				Expect(rejoined.NextMsg(ctx).GetSnapshot().GetState()).Should(Equal("Paused"))
+
+				By("new leader resumes")
+				err = conn2.Resume()
+				Expect(err).ShouldNot(HaveOccurred())
+				each(func(conn *testserver.TestPlayerInRoom) {
+					Expect(conn.NextMsg(ctx).GetResume().GetPlayerId()).Should(Equal(conn2.ID()))
+				}, rejoined, conn2, conn3, conn4)
+
+				By("explainer plays the turn")
+				err = rejoined.Word("abc")
+				Expect(err).ShouldNot(HaveOccurred())
+				each(func(conn *testserver.TestPlayerInRoom) {
+					Expect(conn.NextMsg(ctx).GetWord().GetWord()).Should(Equal("abc"))
+				}, conn3, conn4)
+
+				err = rejoined.EndTurn(1, 0)
+				Expect(err).ShouldNot(HaveOccurred())
+				each(func(conn *testserver.TestPlayerInRoom) {
+					Expect(conn.NextMsg(ctx).GetEndTurn()).ShouldNot(BeNil())
+				}, conn2, conn3, conn4)
+			}, NodeTimeout(time.Second))
+
 			When("wrong player", func() {
 				It("sends word", func(ctx SpecContext) {
 					err := conn4.Word("abc")
@@ -0,0 +1,154 @@
+package socket_test
+
+import (
+	"time"
+
+	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	"github.com/knightpp/alias-server/internal/testutil/matcher"
+	"github.com/knightpp/alias-server/internal/testutil/testserver"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+var _ = Describe("Friends", func() {
+	var (
+		srv            *testserver.TestServer
+		player, friend *testserver.TestPlayer
+	)
+
+	BeforeEach(func(ctx SpecContext) {
+		var err error
+		srv, err = testserver.CreateAndStart()
+		Expect(err).ShouldNot(HaveOccurred())
+
+		players := srv.CreatePlayers(ctx, 2, protoPlayer)
+		player, friend = players[0], players[1]
+	}, NodeTimeout(time.Second))
+
+	befriend := func(ctx SpecContext) {
+		_, err := player.Client().SendFriendRequest(player.AuthContext(ctx), &gamesvc.SendFriendRequestRequest{
+			PlayerId: friend.Proto().Id,
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		_, err = friend.Client().AcceptFriendRequest(friend.AuthContext(ctx), &gamesvc.AcceptFriendRequestRequest{
+			PlayerId: player.Proto().Id,
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+	}
+
+	listFriends := func(ctx SpecContext, p *testserver.TestPlayer) *gamesvc.ListFriendsResponse {
+		resp, err := p.Client().ListFriends(p.AuthContext(ctx), &gamesvc.ListFriendsRequest{})
+		Expect(err).ShouldNot(HaveOccurred())
+		return resp
+	}
+
+	It("sends and accepts a friend request", func(ctx SpecContext) {
+		stream, err := friend.Notifications(ctx)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		_, err = player.Client().SendFriendRequest(player.AuthContext(ctx), &gamesvc.SendFriendRequestRequest{
+			PlayerId: friend.Proto().Id,
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		n, err := stream.Recv()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(n.GetFriendRequest().GetFrom()).Should(matcher.EqualCmp(player.Proto()))
+
+		Expect(listFriends(ctx, player)).Should(matcher.EqualCmp(&gamesvc.ListFriendsResponse{
+			Outgoing: []*gamesvc.Player{friend.Proto()},
+		}))
+		Expect(listFriends(ctx, friend)).Should(matcher.EqualCmp(&gamesvc.ListFriendsResponse{
+			Incoming: []*gamesvc.Player{player.Proto()},
+		}))
+
+		_, err = friend.Client().AcceptFriendRequest(friend.AuthContext(ctx), &gamesvc.AcceptFriendRequestRequest{
+			PlayerId: player.Proto().Id,
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		Expect(listFriends(ctx, player)).Should(matcher.EqualCmp(&gamesvc.ListFriendsResponse{
+			Friends: []*gamesvc.Friend{{Player: friend.Proto()}},
+		}))
+		Expect(listFriends(ctx, friend)).Should(matcher.EqualCmp(&gamesvc.ListFriendsResponse{
+			Friends: []*gamesvc.Friend{{Player: player.Proto()}},
+		}))
+	}, NodeTimeout(time.Second))
+
+	It("cannot accept a request that was not sent", func(ctx SpecContext) {
+		_, err := friend.Client().AcceptFriendRequest(friend.AuthContext(ctx), &gamesvc.AcceptFriendRequestRequest{
+			PlayerId: player.Proto().Id,
+		})
+		Expect(status.Code(err)).Should(Equal(codes.NotFound))
+	}, NodeTimeout(time.Second))
+
+	It("removes a friend", func(ctx SpecContext) {
+		befriend(ctx)
+
+		_, err := friend.Client().RemoveFriend(friend.AuthContext(ctx), &gamesvc.RemoveFriendRequest{
+			PlayerId: player.Proto().Id,
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		Expect(listFriends(ctx, player)).Should(matcher.EqualCmp(&gamesvc.ListFriendsResponse{}))
+	}, NodeTimeout(time.Second))
+
+	It("shows friends online in a room", func(ctx SpecContext) {
+		befriend(ctx)
+
+		conn, err := friend.CreateRoomAndJoin(ctx, protoRoom())
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(conn.NextMsg(ctx).GetUpdateRoom()).ShouldNot(BeNil())
+
+		Expect(listFriends(ctx, player)).Should(matcher.EqualCmp(&gamesvc.ListFriendsResponse{
+			Friends: []*gamesvc.Friend{{
+				Player: friend.Proto(),
+				Online: true,
+				RoomId: testserver.TestUUID,
+			}},
+		}))
+	}, NodeTimeout(time.Second))
+
+	It("invites a friend to a room", func(ctx SpecContext) {
+		befriend(ctx)
+
+		stream, err := friend.Notifications(ctx)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		room := protoRoom()
+		room.Password = proto.String("secret")
+		conn, err := player.CreateRoomAndJoin(ctx, room)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(conn.NextMsg(ctx).GetUpdateRoom()).ShouldNot(BeNil())
+
+		_, err = player.Client().InviteToRoom(player.AuthContext(ctx), &gamesvc.InviteToRoomRequest{
+			PlayerId: friend.Proto().Id,
+			RoomId:   testserver.TestUUID,
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		n, err := stream.Recv()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(n.GetRoomInvite()).Should(matcher.EqualCmp(&gamesvc.RoomInvite{
+			From:     player.Proto(),
+			RoomId:   testserver.TestUUID,
+			RoomName: room.Name,
+			Password: proto.String("secret"),
+		}))
+	}, NodeTimeout(time.Second))
+
+	It("cannot invite a stranger", func(ctx SpecContext) {
+		_, err := player.CreateRoomAndJoin(ctx, protoRoom())
+		Expect(err).ShouldNot(HaveOccurred())
+
+		_, err = player.Client().InviteToRoom(player.AuthContext(ctx), &gamesvc.InviteToRoomRequest{
+			PlayerId: friend.Proto().Id,
+			RoomId:   testserver.TestUUID,
+		})
+		Expect(status.Code(err)).Should(Equal(codes.PermissionDenied))
+	}, NodeTimeout(time.Second))
+})
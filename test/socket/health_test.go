@@ -0,0 +1,87 @@
+package socket_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/knightpp/alias-server/internal/health"
+	"github.com/knightpp/alias-server/internal/storage/mocks"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/mock"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+var _ = Describe("Health", func() {
+	const service = "game_service.GameService"
+
+	var (
+		db      *mocks.Pinger
+		checker *health.Checker
+	)
+
+	BeforeEach(func() {
+		db = mocks.NewPinger(GinkgoT())
+		checker = health.New(zerolog.Nop(), db, health.DefaultOptions(service))
+	})
+
+	grpcStatus := func(ctx SpecContext, service string) healthpb.HealthCheckResponse_ServingStatus {
+		resp, err := checker.Server().Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		Expect(err).ShouldNot(HaveOccurred())
+		return resp.Status
+	}
+
+	readyz := func() int {
+		rec := httptest.NewRecorder()
+		checker.Readyz(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		return rec.Code
+	}
+
+	It("is not ready before the first check", func(ctx SpecContext) {
+		Expect(grpcStatus(ctx, "")).Should(Equal(healthpb.HealthCheckResponse_NOT_SERVING))
+		Expect(readyz()).Should(Equal(http.StatusServiceUnavailable))
+	}, NodeTimeout(time.Second))
+
+	It("is ready when storage is reachable", func(ctx SpecContext) {
+		db.EXPECT().Ping(mock.Anything).Return(nil)
+
+		checker.Check(ctx)
+
+		Expect(grpcStatus(ctx, "")).Should(Equal(healthpb.HealthCheckResponse_SERVING))
+		Expect(grpcStatus(ctx, service)).Should(Equal(healthpb.HealthCheckResponse_SERVING))
+		Expect(readyz()).Should(Equal(http.StatusOK))
+	}, NodeTimeout(time.Second))
+
+	It("is not ready when storage is unreachable", func(ctx SpecContext) {
+		db.EXPECT().Ping(mock.Anything).Return(nil).Once()
+		db.EXPECT().Ping(mock.Anything).Return(errors.New("connection refused")).Once()
+
+		checker.Check(ctx)
+		checker.Check(ctx)
+
+		Expect(grpcStatus(ctx, service)).Should(Equal(healthpb.HealthCheckResponse_NOT_SERVING))
+		Expect(readyz()).Should(Equal(http.StatusServiceUnavailable))
+	}, NodeTimeout(time.Second))
+
+	It("stays not ready while draining", func(ctx SpecContext) {
+		db.EXPECT().Ping(mock.Anything).Return(nil)
+
+		checker.Check(ctx)
+		checker.Drain()
+		checker.Check(ctx)
+
+		Expect(grpcStatus(ctx, "")).Should(Equal(healthpb.HealthCheckResponse_NOT_SERVING))
+		Expect(readyz()).Should(Equal(http.StatusServiceUnavailable))
+	}, NodeTimeout(time.Second))
+
+	It("is alive while draining", func() {
+		checker.Drain()
+
+		rec := httptest.NewRecorder()
+		checker.Healthz(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		Expect(rec.Code).Should(Equal(http.StatusOK))
+	})
+})
@@ -0,0 +1,125 @@
+package socket_test
+
+import (
+	"time"
+
+	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	"github.com/knightpp/alias-server/internal/testutil/matcher"
+	"github.com/knightpp/alias-server/internal/testutil/testserver"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+var _ = Describe("Match history", func() {
+	const teamName = "our team"
+
+	var (
+		conn1  *testserver.TestPlayerInRoom
+		conn2  *testserver.TestPlayerInRoom
+		teamID string
+	)
+
+	BeforeEach(func(ctx SpecContext) {
+		conn1, conn2 = createTwoPlayers(ctx)
+		teamID = startTeamGame(ctx, teamName, conn1, conn2)
+	}, NodeTimeout(time.Second))
+
+	It("is empty for a new player", func(ctx SpecContext) {
+		resp, err := conn1.Client().GetPlayerHistory(ctx, &gamesvc.GetPlayerHistoryRequest{
+			PlayerId: conn1.ID(),
+		})
+
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(resp).Should(matcher.EqualCmp(&gamesvc.GetPlayerHistoryResponse{
+			Stats: &gamesvc.PlayerStats{PlayerId: conn1.ID()},
+		}))
+	}, NodeTimeout(time.Second))
+
+	It("is not recorded for a game without turns", func(ctx SpecContext) {
+		err := conn1.EndGame()
+		Expect(err).ShouldNot(HaveOccurred())
+		each(func(conn *testserver.TestPlayerInRoom) {
+			Expect(conn.NextMsg(ctx).GetResults()).ShouldNot(BeNil())
+		}, conn1, conn2)
+
+		Consistently(ctx, func(g Gomega) {
+			resp, err := conn1.Client().GetPlayerHistory(ctx, &gamesvc.GetPlayerHistoryRequest{
+				PlayerId: conn1.ID(),
+			})
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(resp.Matches).Should(BeEmpty())
+		}).WithTimeout(100 * time.Millisecond).Should(Succeed())
+	}, NodeTimeout(time.Second))
+
+	It("records finished game", func(ctx SpecContext) {
+		finishGame(ctx, 3, 1, conn1, conn2)
+
+		By("fetch explainer history")
+		var history *gamesvc.GetPlayerHistoryResponse
+		Eventually(ctx, func(g Gomega) {
+			resp, err := conn1.Client().GetPlayerHistory(ctx, &gamesvc.GetPlayerHistoryRequest{
+				PlayerId: conn1.ID(),
+			})
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(resp.Matches).Should(HaveLen(1))
+
+			history = resp
+		}).Should(Succeed())
+
+		Expect(history.Stats).Should(matcher.EqualCmp(&gamesvc.PlayerStats{
+			PlayerId:       conn1.ID(),
+			GamesPlayed:    1,
+			Wins:           1,
+			WordsExplained: 3,
+			WordsMissed:    1,
+			Accuracy:       0.75,
+		}))
+
+		match := history.Matches[0]
+		Expect(match).Should(matcher.EqualCmp(&gamesvc.Match{
+			Id:        testserver.TestUUID,
+			RoomId:    testserver.TestUUID,
+			RoomName:  protoRoom().Name,
+			Langugage: protoRoom().Langugage,
+			Teams: []*gamesvc.MatchTeam{{
+				Id:      teamID,
+				Name:    teamName,
+				Players: []*gamesvc.Player{conn1.Proto(), conn2.Proto()},
+				Stats:   &gamesvc.Statistics{Rights: 3, Wrongs: 1},
+			}},
+			Turns: []*gamesvc.MatchTurn{{
+				TeamId:      teamID,
+				ExplainerId: conn1.ID(),
+				GuesserId:   conn2.ID(),
+				Stats:       &gamesvc.Statistics{Rights: 3, Wrongs: 1},
+			}},
+			WinnerTeamId: teamID,
+		}, protocmp.IgnoreFields(&gamesvc.Match{}, "started_at_ms", "ended_at_ms")))
+
+		By("fetch guesser history")
+		resp, err := conn2.Client().GetPlayerHistory(ctx, &gamesvc.GetPlayerHistoryRequest{
+			PlayerId: conn2.ID(),
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(resp.Stats).Should(matcher.EqualCmp(&gamesvc.PlayerStats{
+			PlayerId:     conn2.ID(),
+			GamesPlayed:  1,
+			Wins:         1,
+			WordsGuessed: 3,
+		}))
+
+		By("fetch match")
+		matchResp, err := conn2.Client().GetMatch(ctx, &gamesvc.GetMatchRequest{Id: match.Id})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(matchResp.Match).Should(matcher.EqualCmp(match))
+	}, NodeTimeout(time.Second))
+
+	It("unknown match is not found", func(ctx SpecContext) {
+		_, err := conn1.Client().GetMatch(ctx, &gamesvc.GetMatchRequest{Id: "unknown"})
+
+		Expect(status.Code(err)).Should(Equal(codes.NotFound))
+	}, NodeTimeout(time.Second))
+})
@@ -0,0 +1,121 @@
+package socket_test
+
+import (
+	"time"
+
+	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	"github.com/knightpp/alias-server/internal/game"
+	"github.com/knightpp/alias-server/internal/testutil/matcher"
+	"github.com/knightpp/alias-server/internal/testutil/testserver"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Keepalive", func() {
+	var (
+		opts         game.Options
+		srv          *testserver.TestServer
+		roomID       string
+		conn1, conn2 *testserver.TestPlayerInRoom
+	)
+
+	BeforeEach(func() {
+		opts = game.DefaultOptions()
+		opts.Player.Heartbeat = 0
+		opts.IdleTimeout = 0
+	})
+
+	JustBeforeEach(func(ctx SpecContext) {
+		var err error
+		srv, err = testserver.CreateAndStartWithOptions(testserver.Options{
+			Game: &opts,
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		players := srv.CreatePlayers(ctx, 2, protoPlayer)
+
+		roomID, err = players[0].CreateRoom(ctx, protoRoom())
+		Expect(err).ShouldNot(HaveOccurred())
+
+		conns := srv.JoinPlayers(ctx, roomID, players...)
+		conn1, conn2 = conns[0], conns[1]
+	}, NodeTimeout(time.Second))
+
+	It("answers pings", func(ctx SpecContext) {
+		Expect(conn1.Ping(42)).Should(Succeed())
+
+		Expect(conn1.NextMsg(ctx)).Should(matcher.EqualCmp(&gamesvc.Message{
+			Message: &gamesvc.Message_Pong{
+				Pong: &gamesvc.MsgPong{Timestamp: 42},
+			},
+		}))
+	}, NodeTimeout(time.Second))
+
+	Context("with heartbeat", func() {
+		BeforeEach(func() {
+			opts.Player.Heartbeat = 50 * time.Millisecond
+		})
+
+		It("pings players who send nothing", func(ctx SpecContext) {
+			ping := conn1.NextMsg(ctx).GetPing()
+			Expect(ping).ShouldNot(BeNil())
+			Expect(ping.Timestamp).Should(BeNumerically(">", 0))
+
+			Expect(conn1.Pong(ping.Timestamp)).Should(Succeed())
+		}, NodeTimeout(time.Second))
+	})
+
+	Context("with idle timeout", func() {
+		BeforeEach(func() {
+			opts.IdleTimeout = 200 * time.Millisecond
+		})
+
+		It("kicks idle players from the lobby", func(ctx SpecContext) {
+			ticker := time.NewTicker(50 * time.Millisecond)
+			defer ticker.Stop()
+
+			// the second player stays active until the first one is kicked
+			var lobby []*gamesvc.Player
+			for lobby == nil {
+				select {
+				case <-ticker.C:
+					Expect(conn2.Ping(1)).Should(Succeed())
+				case msg := <-conn2.C:
+					lobby = msg.GetUpdateRoom().GetRoom().GetLobby()
+				case <-ctx.Done():
+					Fail("the idle player was not kicked")
+				}
+			}
+			Expect(lobby).Should(HaveLen(1))
+			Expect(lobby[0].Id).Should(Equal(conn2.ID()))
+
+			Expect(conn1.NextMsg(ctx).GetServerNotice().GetText()).Should(Equal("You were disconnected for inactivity"))
+			Eventually(ctx, conn1.Closed()).Should(BeClosed())
+		}, NodeTimeout(time.Second))
+
+		It("doesn't kick spectators of a game", func(ctx SpecContext) {
+			startTeamGame(ctx, "team", conn1, conn2)
+
+			spectator, err := srv.NewPlayer(ctx, protoPlayer(3))
+			Expect(err).ShouldNot(HaveOccurred())
+			conn3, err := spectator.Join(roomID)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			timer := time.NewTimer(2 * opts.IdleTimeout)
+			defer timer.Stop()
+
+			for {
+				select {
+				case msg := <-conn3.C:
+					Expect(msg.GetServerNotice()).Should(BeNil())
+				case <-conn3.Closed():
+					Fail("the spectator was kicked")
+				case <-timer.C:
+					return
+				case <-ctx.Done():
+					return
+				}
+			}
+		}, NodeTimeout(time.Second))
+	})
+})
@@ -0,0 +1,103 @@
+package socket_test
+
+import (
+	"time"
+
+	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	"github.com/knightpp/alias-server/internal/rating"
+	"github.com/knightpp/alias-server/internal/testutil/matcher"
+	"github.com/knightpp/alias-server/internal/testutil/testserver"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var _ = Describe("Leaderboard", func() {
+	var (
+		conn1 *testserver.TestPlayerInRoom
+		conn2 *testserver.TestPlayerInRoom
+	)
+
+	BeforeEach(func(ctx SpecContext) {
+		conn1, conn2 = createTwoPlayers(ctx)
+		startTeamGame(ctx, "our team", conn1, conn2)
+
+		finishGame(ctx, 5, 0, conn1, conn2)
+	}, NodeTimeout(time.Second))
+
+	It("ranks winners by wins", func(ctx SpecContext) {
+		Eventually(ctx, func(g Gomega) {
+			resp, err := conn1.Client().GetLeaderboard(ctx, &gamesvc.GetLeaderboardRequest{
+				Kind: gamesvc.LeaderboardKind_LEADERBOARD_KIND_WINS,
+			})
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(resp).Should(matcher.EqualCmp(&gamesvc.GetLeaderboardResponse{
+				Entries: []*gamesvc.LeaderboardEntry{
+					{Rank: 1, Player: conn2.Proto(), Score: 1},
+					{Rank: 2, Player: conn1.Proto(), Score: 1},
+				},
+			}))
+		}).Should(Succeed())
+	}, NodeTimeout(time.Second))
+
+	It("filters by language and window", func(ctx SpecContext) {
+		Eventually(ctx, func(g Gomega) {
+			resp, err := conn1.Client().GetLeaderboard(ctx, &gamesvc.GetLeaderboardRequest{
+				Kind:      gamesvc.LeaderboardKind_LEADERBOARD_KIND_RATING,
+				Langugage: protoRoom().Langugage,
+				Window:    gamesvc.LeaderboardWindow_LEADERBOARD_WINDOW_ALL_TIME,
+			})
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(resp.Entries).Should(HaveLen(2))
+			g.Expect(resp.Entries[0].Score).Should(Equal(rating.Initial))
+		}).Should(Succeed())
+
+		resp, err := conn1.Client().GetLeaderboard(ctx, &gamesvc.GetLeaderboardRequest{
+			Kind:      gamesvc.LeaderboardKind_LEADERBOARD_KIND_WINS,
+			Langugage: "EN",
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(resp.Entries).Should(BeEmpty())
+
+		resp, err = conn1.Client().GetLeaderboard(ctx, &gamesvc.GetLeaderboardRequest{
+			Kind:   gamesvc.LeaderboardKind_LEADERBOARD_KIND_WINS,
+			Window: gamesvc.LeaderboardWindow_LEADERBOARD_WINDOW_WEEKLY,
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(resp.Entries).Should(HaveLen(2))
+	}, NodeTimeout(time.Second))
+
+	It("paginates", func(ctx SpecContext) {
+		var first *gamesvc.GetLeaderboardResponse
+		Eventually(ctx, func(g Gomega) {
+			resp, err := conn1.Client().GetLeaderboard(ctx, &gamesvc.GetLeaderboardRequest{
+				PageSize: 1,
+			})
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(resp.Entries).Should(HaveLen(1))
+
+			first = resp
+		}).Should(Succeed())
+		Expect(first.NextPageToken).ShouldNot(BeEmpty())
+
+		second, err := conn1.Client().GetLeaderboard(ctx, &gamesvc.GetLeaderboardRequest{
+			PageSize:  1,
+			PageToken: first.NextPageToken,
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(second).Should(matcher.EqualCmp(&gamesvc.GetLeaderboardResponse{
+			Entries: []*gamesvc.LeaderboardEntry{
+				{Rank: 2, Player: conn1.Proto(), Score: 1},
+			},
+		}))
+	}, NodeTimeout(time.Second))
+
+	It("rejects invalid page token", func(ctx SpecContext) {
+		_, err := conn1.Client().GetLeaderboard(ctx, &gamesvc.GetLeaderboardRequest{
+			PageToken: "abc",
+		})
+
+		Expect(status.Code(err)).Should(Equal(codes.InvalidArgument))
+	}, NodeTimeout(time.Second))
+})
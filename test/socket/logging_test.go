@@ -0,0 +1,74 @@
+package socket_test
+
+import (
+	"time"
+
+	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	"github.com/knightpp/alias-server/internal/logging"
+	"github.com/knightpp/alias-server/internal/testutil/matcher"
+	"github.com/knightpp/alias-server/internal/testutil/testserver"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+var _ = Describe("Logging", func() {
+	It("redacts passwords", func() {
+		req := &gamesvc.CreateRoomRequest{
+			Name:     "room",
+			Password: proto.String("secret"),
+		}
+
+		Expect(logging.Redact(req)).Should(matcher.EqualCmp(&gamesvc.CreateRoomRequest{
+			Name:     "room",
+			Password: proto.String("[REDACTED]"),
+		}))
+		Expect(req.GetPassword()).Should(Equal("secret"))
+	})
+
+	It("parses levels of components", func() {
+		levels, err := logging.ParseComponents("game=debug, grpc=warn,")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(levels).Should(Equal(map[string]zerolog.Level{
+			"game": zerolog.DebugLevel,
+			"grpc": zerolog.WarnLevel,
+		}))
+
+		_, err = logging.ParseComponents("game")
+		Expect(err).Should(HaveOccurred())
+
+		_, err = logging.ParseComponents("game=loud")
+		Expect(err).Should(HaveOccurred())
+	})
+
+	Context("request ID", func() {
+		var player *testserver.TestPlayer
+
+		BeforeEach(func(ctx SpecContext) {
+			srv, err := testserver.CreateAndStart()
+			Expect(err).ShouldNot(HaveOccurred())
+
+			player, err = srv.NewPlayer(ctx, protoPlayer(1))
+			Expect(err).ShouldNot(HaveOccurred())
+		}, NodeTimeout(time.Second))
+
+		It("is generated", func(ctx SpecContext) {
+			var header metadata.MD
+			_, err := player.Client().ListRooms(ctx, &gamesvc.ListRoomsRequest{}, grpc.Header(&header))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(header.Get(logging.RequestIDKey)).Should(Equal([]string{testserver.TestUUID}))
+		}, NodeTimeout(time.Second))
+
+		It("is taken from the client", func(ctx SpecContext) {
+			reqCtx := metadata.AppendToOutgoingContext(ctx, logging.RequestIDKey, "my-request")
+
+			var header metadata.MD
+			_, err := player.Client().ListRooms(reqCtx, &gamesvc.ListRoomsRequest{}, grpc.Header(&header))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(header.Get(logging.RequestIDKey)).Should(Equal([]string{"my-request"}))
+		}, NodeTimeout(time.Second))
+	})
+})
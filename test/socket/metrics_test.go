@@ -0,0 +1,60 @@
+package socket_test
+
+import (
+	"time"
+
+	"github.com/knightpp/alias-server/internal/metrics"
+	"github.com/knightpp/alias-server/internal/testutil/testserver"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+var _ = Describe("Metrics", func() {
+	var conn1, conn2 *testserver.TestPlayerInRoom
+
+	BeforeEach(func(ctx SpecContext) {
+		conn1, conn2 = createTwoPlayers(ctx)
+	}, NodeTimeout(time.Second))
+
+	It("count rooms and players", func() {
+		Expect(testutil.ToFloat64(metrics.RoomsActive)).Should(BeNumerically(">=", 1))
+		Expect(testutil.ToFloat64(metrics.RoomsByState.WithLabelValues("Lobby"))).Should(BeNumerically(">=", 1))
+		Expect(testutil.ToFloat64(metrics.PlayersConnected)).Should(BeNumerically(">=", 2))
+	})
+
+	It("count handled messages by type", func(ctx SpecContext) {
+		chats := metrics.MessagesHandled.WithLabelValues("chat")
+		before := testutil.ToFloat64(chats)
+
+		err := conn1.Chat("hello")
+		Expect(err).ShouldNot(HaveOccurred())
+		each(func(conn *testserver.TestPlayerInRoom) {
+			Expect(conn.NextMsg(ctx).GetChat()).ShouldNot(BeNil())
+		}, conn1, conn2)
+
+		Expect(testutil.ToFloat64(chats)).Should(Equal(before + 1))
+	}, NodeTimeout(time.Second))
+
+	It("count messages unknown to the state", func(ctx SpecContext) {
+		unknown := metrics.UnknownMessages.WithLabelValues("Lobby", "start_turn")
+		before := testutil.ToFloat64(unknown)
+
+		err := conn1.StartTurn(time.Minute)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(conn1.NextMsg(ctx).GetError()).ShouldNot(BeNil())
+
+		Expect(testutil.ToFloat64(unknown)).Should(Equal(before + 1))
+	}, NodeTimeout(time.Second))
+
+	It("count rooms by state", func(ctx SpecContext) {
+		games := metrics.RoomsByState.WithLabelValues("Game")
+		before := testutil.ToFloat64(games)
+
+		startTeamGame(ctx, "team", conn1, conn2)
+
+		Eventually(func() float64 {
+			return testutil.ToFloat64(games)
+		}).Should(Equal(before + 1))
+	}, NodeTimeout(time.Second))
+})
@@ -0,0 +1,163 @@
+package socket_test
+
+import (
+	"time"
+
+	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	"github.com/knightpp/alias-server/internal/testutil/matcher"
+	"github.com/knightpp/alias-server/internal/testutil/testserver"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var _ = Describe("Party", func() {
+	var leader, member, host *testserver.TestPlayer
+
+	BeforeEach(func(ctx SpecContext) {
+		srv, err := testserver.CreateAndStart()
+		Expect(err).ShouldNot(HaveOccurred())
+
+		players := srv.CreatePlayers(ctx, 3, protoPlayer)
+		leader, member, host = players[0], players[1], players[2]
+
+		_, err = leader.Client().SendFriendRequest(leader.AuthContext(ctx), &gamesvc.SendFriendRequestRequest{
+			PlayerId: member.Proto().Id,
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		_, err = member.Client().AcceptFriendRequest(member.AuthContext(ctx), &gamesvc.AcceptFriendRequestRequest{
+			PlayerId: leader.Proto().Id,
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+	}, NodeTimeout(time.Second))
+
+	formParty := func(ctx SpecContext) *gamesvc.Party {
+		invite, err := leader.Client().InviteToParty(leader.AuthContext(ctx), &gamesvc.InviteToPartyRequest{
+			PlayerId: member.Proto().Id,
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		resp, err := member.Client().JoinParty(member.AuthContext(ctx), &gamesvc.JoinPartyRequest{
+			PartyId: invite.Party.Id,
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		return resp.Party
+	}
+
+	It("forms a party from an invite", func(ctx SpecContext) {
+		memberStream, err := member.Notifications(ctx)
+		Expect(err).ShouldNot(HaveOccurred())
+		leaderStream, err := leader.Notifications(ctx)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		invite, err := leader.Client().InviteToParty(leader.AuthContext(ctx), &gamesvc.InviteToPartyRequest{
+			PlayerId: member.Proto().Id,
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(invite.Party).Should(matcher.EqualCmp(&gamesvc.Party{
+			Id:       testserver.TestUUID,
+			LeaderId: leader.Proto().Id,
+			Members:  []*gamesvc.Player{leader.Proto()},
+		}))
+
+		n, err := memberStream.Recv()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(n.GetPartyInvite()).Should(matcher.EqualCmp(&gamesvc.PartyInvite{
+			From:  leader.Proto(),
+			Party: invite.Party,
+		}))
+
+		party := &gamesvc.Party{
+			Id:       testserver.TestUUID,
+			LeaderId: leader.Proto().Id,
+			Members:  []*gamesvc.Player{leader.Proto(), member.Proto()},
+		}
+		Expect(formParty(ctx)).Should(matcher.EqualCmp(party))
+
+		n, err = leaderStream.Recv()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(n.GetPartyUpdate().GetParty()).Should(matcher.EqualCmp(party))
+
+		resp, err := leader.Client().GetParty(leader.AuthContext(ctx), &gamesvc.GetPartyRequest{})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(resp.Party).Should(matcher.EqualCmp(party))
+	}, NodeTimeout(time.Second))
+
+	It("cannot be joined without an invite", func(ctx SpecContext) {
+		invite, err := leader.Client().InviteToParty(leader.AuthContext(ctx), &gamesvc.InviteToPartyRequest{
+			PlayerId: member.Proto().Id,
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		_, err = host.Client().JoinParty(host.AuthContext(ctx), &gamesvc.JoinPartyRequest{
+			PartyId: invite.Party.Id,
+		})
+		Expect(status.Code(err)).Should(Equal(codes.PermissionDenied))
+	}, NodeTimeout(time.Second))
+
+	It("disbands when a member leaves", func(ctx SpecContext) {
+		formParty(ctx)
+
+		_, err := member.Client().LeaveParty(member.AuthContext(ctx), &gamesvc.LeavePartyRequest{})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		_, err = leader.Client().GetParty(leader.AuthContext(ctx), &gamesvc.GetPartyRequest{})
+		Expect(status.Code(err)).Should(Equal(codes.NotFound))
+	}, NodeTimeout(time.Second))
+
+	It("follows the leader into the same team", func(ctx SpecContext) {
+		formParty(ctx)
+
+		hostConn, err := host.CreateRoomAndJoin(ctx, protoRoom())
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(hostConn.NextMsg(ctx).GetUpdateRoom()).ShouldNot(BeNil())
+
+		err = hostConn.CreateTeam("team")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(hostConn.NextMsg(ctx).GetTeamCreated()).ShouldNot(BeNil())
+
+		memberStream, err := member.Notifications(ctx)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		leaderConn, err := leader.Join(testserver.TestUUID)
+		Expect(err).ShouldNot(HaveOccurred())
+		each(func(conn *testserver.TestPlayerInRoom) {
+			Expect(conn.NextMsg(ctx).GetUpdateRoom()).ShouldNot(BeNil())
+		}, hostConn, leaderConn)
+
+		n, err := memberStream.Recv()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(n.GetPartyFollow()).Should(matcher.EqualCmp(&gamesvc.PartyFollow{
+			RoomId: testserver.TestUUID,
+		}))
+
+		memberConn, err := member.Join(n.GetPartyFollow().RoomId)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		var room *gamesvc.Room
+		each(func(conn *testserver.TestPlayerInRoom) {
+			update := conn.NextMsg(ctx).GetUpdateRoom()
+			Expect(update).ShouldNot(BeNil())
+			room = update.Room
+		}, hostConn, leaderConn, memberConn)
+
+		Expect(room.Lobby).Should(HaveLen(1))
+		Expect(room.Lobby[0]).Should(matcher.EqualCmp(host.Proto()))
+		Expect(room.Teams).Should(HaveLen(1))
+		Expect(room.Teams[0].PlayerA).Should(matcher.EqualCmp(leader.Proto()))
+		Expect(room.Teams[0].PlayerB).Should(matcher.EqualCmp(member.Proto()))
+	}, NodeTimeout(time.Second))
+
+	It("only lets the leader queue", func(ctx SpecContext) {
+		formParty(ctx)
+
+		stream, err := member.QuickMatch(ctx, &gamesvc.QuickMatchRequest{})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		_, err = stream.Recv()
+		Expect(status.Code(err)).Should(Equal(codes.FailedPrecondition))
+	}, NodeTimeout(time.Second))
+})
@@ -0,0 +1,116 @@
+package socket_test
+
+import (
+	"context"
+	"io"
+	"time"
+
+	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	"github.com/knightpp/alias-server/internal/game/entity"
+	"github.com/knightpp/alias-server/internal/testutil/matcher"
+	"github.com/knightpp/alias-server/internal/testutil/transport"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rs/zerolog"
+)
+
+var _ = Describe("Player", func() {
+	var (
+		conn   *transport.Memory
+		opts   entity.PlayerOptions
+		player *entity.Player
+		// started has the result of Start
+		started chan error
+	)
+
+	notice := func(text string) *gamesvc.Message {
+		return &gamesvc.Message{
+			Message: &gamesvc.Message_ServerNotice{
+				ServerNotice: &gamesvc.MsgServerNotice{Text: text},
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		DeferCleanup(cancel)
+
+		conn = transport.NewMemory(ctx)
+		opts = entity.DefaultPlayerOptions()
+		opts.QueueSize = 2
+		opts.FlushTimeout = 50 * time.Millisecond
+	})
+
+	JustBeforeEach(func() {
+		player = entity.NewPlayer(zerolog.Nop(), conn, protoPlayer(1), nil, opts)
+
+		p, result := player, make(chan error, 1)
+		go func() {
+			result <- p.Start(context.Background())
+		}()
+		started = result
+	})
+
+	It("sends queued messages in order", func(ctx SpecContext) {
+		Expect(player.SendMsg(notice("1"))).Should(Succeed())
+		Expect(player.SendMsg(notice("2"))).Should(Succeed())
+
+		Eventually(ctx, conn.Out).Should(Receive(matcher.EqualCmp(notice("1"))))
+		Eventually(ctx, conn.Out).Should(Receive(matcher.EqualCmp(notice("2"))))
+	}, NodeTimeout(time.Second))
+
+	It("ends when the connection is closed", func(ctx SpecContext) {
+		close(conn.In)
+
+		Eventually(ctx, started).Should(Receive(MatchError(io.EOF)))
+	}, NodeTimeout(time.Second))
+
+	It("sends queued messages before it's disconnected", func(ctx SpecContext) {
+		Expect(player.SendMsg(notice("kicked"))).Should(Succeed())
+		player.Disconnect()
+
+		Eventually(ctx, conn.Out).Should(Receive(matcher.EqualCmp(notice("kicked"))))
+		Eventually(ctx, started).Should(Receive(BeNil()))
+		Expect(player.SendMsg(notice("late"))).Should(MatchError(entity.ErrDisconnected))
+	}, NodeTimeout(time.Second))
+
+	It("disconnects a slow player", func(ctx SpecContext) {
+		// the writer takes one message and waits for the player, the
+		// queue takes two more
+		Eventually(func() error {
+			return player.SendMsg(notice("spam"))
+		}).WithContext(ctx).Should(MatchError(entity.ErrSlowConsumer))
+
+		Eventually(ctx, started).Should(Receive(MatchError(entity.ErrSlowConsumer)))
+	}, NodeTimeout(time.Second))
+
+	Context("with drop policy", func() {
+		BeforeEach(func() {
+			opts.SlowConsumer = entity.SlowConsumerDrop
+		})
+
+		It("drops messages of a slow player", func(ctx SpecContext) {
+			for i := 0; i < 10; i++ {
+				Expect(player.SendMsg(notice("spam"))).Should(Succeed())
+			}
+
+			Consistently(started, 50*time.Millisecond).ShouldNot(Receive())
+
+			var received int
+			for received < 10 {
+				select {
+				case msg := <-conn.Out:
+					Expect(msg).Should(matcher.EqualCmp(notice("spam")))
+					received += 1
+					continue
+				case <-time.After(50 * time.Millisecond):
+				}
+				break
+			}
+			Expect(received).Should(BeNumerically("<", 10))
+
+			Expect(player.SendMsg(notice("caught up"))).Should(Succeed())
+			Eventually(ctx, conn.Out).Should(Receive(matcher.EqualCmp(notice("caught up"))))
+		}, NodeTimeout(time.Second))
+	})
+})
@@ -0,0 +1,131 @@
+package socket_test
+
+import (
+	"context"
+	"time"
+
+	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	"github.com/knightpp/alias-server/internal/testutil/testserver"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var _ = Describe("Quick match", func() {
+	var players []*testserver.TestPlayer
+
+	BeforeEach(func(ctx SpecContext) {
+		srv, err := testserver.CreateAndStart()
+		Expect(err).ShouldNot(HaveOccurred())
+
+		players = srv.CreatePlayers(ctx, 4, protoPlayer)
+	}, NodeTimeout(time.Second))
+
+	It("forms a room with complete teams", func(ctx SpecContext) {
+		req := &gamesvc.QuickMatchRequest{Langugage: "UA", Teams: 2}
+
+		streams := make([]gamesvc.GameService_QuickMatchClient, len(players))
+		for i, player := range players {
+			stream, err := player.QuickMatch(ctx, req)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			update, err := stream.Recv()
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(update.GetQueued()).ShouldNot(BeNil())
+
+			streams[i] = stream
+		}
+
+		var roomID string
+		for _, stream := range streams {
+			update, err := stream.Recv()
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(update.GetFound()).ShouldNot(BeNil())
+
+			if roomID == "" {
+				roomID = update.GetFound().RoomId
+			}
+			Expect(update.GetFound().RoomId).Should(Equal(roomID))
+		}
+
+		var room *gamesvc.Room
+		conns := make([]*testserver.TestPlayerInRoom, 0, len(players))
+		for _, player := range players {
+			conn, err := player.Join(roomID)
+			Expect(err).ShouldNot(HaveOccurred())
+			conns = append(conns, conn)
+
+			for _, conn := range conns {
+				update := conn.NextMsg(ctx).GetUpdateRoom()
+				Expect(update).ShouldNot(BeNil())
+				room = update.Room
+			}
+		}
+
+		By("every player is in a team")
+		Expect(room.Lobby).Should(BeEmpty())
+		Expect(room.Teams).Should(HaveLen(2))
+		for _, team := range room.Teams {
+			Expect(team.PlayerA).ShouldNot(BeNil())
+			Expect(team.PlayerB).ShouldNot(BeNil())
+		}
+	}, NodeTimeout(5*time.Second))
+
+	It("leaves the queue when canceled", func(ctx SpecContext) {
+		queueCtx, cancel := context.WithCancel(ctx)
+		stream, err := players[0].QuickMatch(queueCtx, &gamesvc.QuickMatchRequest{})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		update, err := stream.Recv()
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(update.GetQueued()).ShouldNot(BeNil())
+
+		cancel()
+		_, err = stream.Recv()
+		Expect(status.Code(err)).Should(Equal(codes.Canceled))
+
+		By("queue again")
+		Eventually(ctx, func(g Gomega) {
+			queueCtx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			stream, err := players[0].QuickMatch(queueCtx, &gamesvc.QuickMatchRequest{})
+			g.Expect(err).ShouldNot(HaveOccurred())
+
+			update, err := stream.Recv()
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(update.GetQueued()).ShouldNot(BeNil())
+
+			cancel()
+			_, err = stream.Recv()
+			g.Expect(status.Code(err)).Should(Equal(codes.Canceled))
+		}).Should(Succeed())
+	}, NodeTimeout(5*time.Second))
+
+	It("cannot queue twice", func(ctx SpecContext) {
+		stream, err := players[0].QuickMatch(ctx, &gamesvc.QuickMatchRequest{})
+		Expect(err).ShouldNot(HaveOccurred())
+		_, err = stream.Recv()
+		Expect(err).ShouldNot(HaveOccurred())
+
+		Eventually(ctx, func(g Gomega) {
+			stream, err := players[0].QuickMatch(ctx, &gamesvc.QuickMatchRequest{})
+			g.Expect(err).ShouldNot(HaveOccurred())
+
+			_, err = stream.Recv()
+			g.Expect(err).ShouldNot(HaveOccurred())
+
+			_, err = stream.Recv()
+			g.Expect(status.Code(err)).Should(Equal(codes.AlreadyExists))
+		}).Should(Succeed())
+	}, NodeTimeout(5*time.Second))
+
+	It("rejects too many teams", func(ctx SpecContext) {
+		stream, err := players[0].QuickMatch(ctx, &gamesvc.QuickMatchRequest{Teams: 100})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		_, err = stream.Recv()
+		Expect(status.Code(err)).Should(Equal(codes.InvalidArgument))
+	}, NodeTimeout(time.Second))
+})
@@ -0,0 +1,101 @@
+package socket_test
+
+import (
+	"time"
+
+	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	"github.com/knightpp/alias-server/internal/game"
+	"github.com/knightpp/alias-server/internal/ratelimit"
+	"github.com/knightpp/alias-server/internal/testutil/testserver"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var _ = Describe("Rate limit", func() {
+	var (
+		limitOpts ratelimit.Options
+		gameOpts  game.Options
+		srv       *testserver.TestServer
+		players   []*testserver.TestPlayer
+	)
+
+	method := func(name string) string {
+		return "/" + gamesvc.GameService_ServiceDesc.ServiceName + "/" + name
+	}
+
+	BeforeEach(func() {
+		limitOpts = ratelimit.DefaultOptions()
+		gameOpts = game.DefaultOptions()
+	})
+
+	JustBeforeEach(func(ctx SpecContext) {
+		var err error
+		srv, err = testserver.CreateAndStartWithOptions(testserver.Options{
+			Game:      &gameOpts,
+			RateLimit: &limitOpts,
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		players = srv.CreatePlayers(ctx, 2, protoPlayer)
+	}, NodeTimeout(time.Second))
+
+	Context("of a method", func() {
+		BeforeEach(func() {
+			limitOpts.Methods[method("CreateRoom")] = ratelimit.Every(time.Minute, 2)
+		})
+
+		It("limits every player", func(ctx SpecContext) {
+			for i := 0; i < 2; i++ {
+				_, err := players[0].CreateRoom(ctx, protoRoom())
+				Expect(err).ShouldNot(HaveOccurred())
+			}
+
+			_, err := players[0].CreateRoom(ctx, protoRoom())
+			Expect(status.Code(err)).Should(Equal(codes.ResourceExhausted))
+
+			_, err = players[1].CreateRoom(ctx, protoRoom())
+			Expect(err).ShouldNot(HaveOccurred())
+		}, NodeTimeout(time.Second))
+	})
+
+	Context("by default", func() {
+		BeforeEach(func() {
+			limitOpts.Default = ratelimit.Every(time.Minute, 2)
+		})
+
+		It("limits unauthenticated clients by IP", func(ctx SpecContext) {
+			for i := 0; i < 2; i++ {
+				_, err := players[0].Client().ListRooms(ctx, &gamesvc.ListRoomsRequest{})
+				Expect(err).ShouldNot(HaveOccurred())
+			}
+
+			// players share the address
+			_, err := players[1].Client().ListRooms(ctx, &gamesvc.ListRoomsRequest{})
+			Expect(status.Code(err)).Should(Equal(codes.ResourceExhausted))
+		}, NodeTimeout(time.Second))
+	})
+
+	Context("of a message type", func() {
+		BeforeEach(func() {
+			gameOpts.Messages.Types["create_team"] = ratelimit.Every(time.Minute, 1)
+		})
+
+		It("rejects messages over the limit", func(ctx SpecContext) {
+			conn, err := players[0].CreateRoomAndJoin(ctx, protoRoom())
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Expect(conn.CreateTeam("first")).Should(Succeed())
+			Expect(conn.CreateTeam("second")).Should(Succeed())
+
+			var rejected *gamesvc.MsgError
+			for rejected == nil {
+				msg := conn.NextMsg(ctx)
+				Expect(msg).ShouldNot(BeNil())
+				rejected = msg.GetError()
+			}
+			Expect(rejected.Error).Should(Equal("too many create_team messages"))
+		}, NodeTimeout(time.Second))
+	})
+})
@@ -0,0 +1,142 @@
+package socket_test
+
+import (
+	"time"
+
+	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	"github.com/knightpp/alias-server/internal/emoji"
+	"github.com/knightpp/alias-server/internal/testutil/matcher"
+	"github.com/knightpp/alias-server/internal/testutil/testserver"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Reactions", func() {
+	var conn1, conn2 *testserver.TestPlayerInRoom
+
+	BeforeEach(func(ctx SpecContext) {
+		conn1, conn2 = createTwoPlayers(ctx)
+	}, NodeTimeout(time.Second))
+
+	reactionMsg := func(conn *testserver.TestPlayerInRoom, emoji string) *gamesvc.Message {
+		return &gamesvc.Message{
+			Message: &gamesvc.Message_Reaction{
+				Reaction: &gamesvc.MsgReaction{
+					PlayerId: conn.ID(),
+					Emoji:    emoji,
+				},
+			},
+		}
+	}
+
+	It("are not allowed before a game", func(ctx SpecContext) {
+		err := conn1.React("😀")
+		Expect(err).ShouldNot(HaveOccurred())
+
+		Expect(conn1.NextMsg(ctx).GetError()).ShouldNot(BeNil())
+	}, NodeTimeout(time.Second))
+
+	When("in a turn", func() {
+		BeforeEach(func(ctx SpecContext) {
+			startTeamGame(ctx, "team", conn1, conn2)
+			startTurn(ctx, conn1, conn2)
+		}, NodeTimeout(time.Second))
+
+		It("broadcasts a reaction to the room", func(ctx SpecContext) {
+			err := conn2.React("😀")
+			Expect(err).ShouldNot(HaveOccurred())
+
+			each(func(conn *testserver.TestPlayerInRoom) {
+				Expect(conn.NextMsg(ctx)).Should(matcher.EqualCmp(reactionMsg(conn2, "😀")))
+			}, conn1, conn2)
+		}, NodeTimeout(time.Second))
+
+		It("rejects text that is not an allowed emoji", func(ctx SpecContext) {
+			for _, text := range []string{"hi", "❤", "😀😀"} {
+				err := conn2.React(text)
+				Expect(err).ShouldNot(HaveOccurred())
+
+				Expect(conn2.NextMsg(ctx).GetError().GetError()).Should(Equal("reaction is not an allowed emoji"))
+			}
+		}, NodeTimeout(time.Second))
+
+		It("limits how often a player can react", func(ctx SpecContext) {
+			for i := 0; i < 4; i++ {
+				err := conn2.React("🍕")
+				Expect(err).ShouldNot(HaveOccurred())
+			}
+
+			for i := 0; i < 3; i++ {
+				each(func(conn *testserver.TestPlayerInRoom) {
+					Expect(conn.NextMsg(ctx)).Should(matcher.EqualCmp(reactionMsg(conn2, "🍕")))
+				}, conn1, conn2)
+			}
+			Expect(conn2.NextMsg(ctx).GetError().GetError()).Should(Equal("too many reactions"))
+		}, NodeTimeout(time.Second))
+
+		It("are allowed on results", func(ctx SpecContext) {
+			err := conn1.EndTurn(1, 0)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(conn2.NextMsg(ctx).GetEndTurn()).ShouldNot(BeNil())
+
+			err = conn1.EndGame()
+			Expect(err).ShouldNot(HaveOccurred())
+			each(func(conn *testserver.TestPlayerInRoom) {
+				Expect(conn.NextMsg(ctx).GetResults()).ShouldNot(BeNil())
+			}, conn1, conn2)
+
+			err = conn2.React("🐶")
+			Expect(err).ShouldNot(HaveOccurred())
+			each(func(conn *testserver.TestPlayerInRoom) {
+				Expect(conn.NextMsg(ctx)).Should(matcher.EqualCmp(reactionMsg(conn2, "🐶")))
+			}, conn1, conn2)
+		}, NodeTimeout(time.Second))
+
+		It("are not allowed once teams change after results", func(ctx SpecContext) {
+			err := conn1.EndTurn(1, 0)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(conn2.NextMsg(ctx).GetEndTurn()).ShouldNot(BeNil())
+
+			err = conn1.EndGame()
+			Expect(err).ShouldNot(HaveOccurred())
+			each(func(conn *testserver.TestPlayerInRoom) {
+				Expect(conn.NextMsg(ctx).GetResults()).ShouldNot(BeNil())
+			}, conn1, conn2)
+
+			err = conn1.CreateTeam("another team")
+			Expect(err).ShouldNot(HaveOccurred())
+			each(func(conn *testserver.TestPlayerInRoom) {
+				Expect(conn.NextMsg(ctx).GetTeamCreated()).ShouldNot(BeNil())
+			}, conn1, conn2)
+
+			err = conn2.React("🐶")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(conn2.NextMsg(ctx).GetError().GetError()).Should(Equal("reactions are allowed during a turn and on results"))
+		}, NodeTimeout(time.Second))
+	})
+})
+
+var _ = Describe("Team badge", func() {
+	var conn1, conn2 *testserver.TestPlayerInRoom
+
+	BeforeEach(func(ctx SpecContext) {
+		conn1, conn2 = createTwoPlayers(ctx)
+	}, NodeTimeout(time.Second))
+
+	It("is given to a team with a generated name", func(ctx SpecContext) {
+		err := conn1.CreateTeam("")
+		Expect(err).ShouldNot(HaveOccurred())
+
+		team := conn1.NextMsg(ctx).GetTeamCreated().GetTeam()
+		Expect(team.Name).ShouldNot(BeEmpty())
+		Expect(emoji.IsOneOf(team.Badge)).Should(BeTrue())
+		Expect(conn2.NextMsg(ctx).GetTeamCreated().GetTeam()).Should(matcher.EqualCmp(team))
+	}, NodeTimeout(time.Second))
+
+	It("is not given to a named team", func(ctx SpecContext) {
+		err := conn1.CreateTeam("named")
+		Expect(err).ShouldNot(HaveOccurred())
+
+		Expect(conn1.NextMsg(ctx).GetTeamCreated().GetTeam().Badge).Should(BeEmpty())
+	}, NodeTimeout(time.Second))
+})
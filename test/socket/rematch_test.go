@@ -0,0 +1,113 @@
+package socket_test
+
+import (
+	"time"
+
+	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	"github.com/knightpp/alias-server/internal/testutil/matcher"
+	"github.com/knightpp/alias-server/internal/testutil/testserver"
+	"github.com/knightpp/alias-server/internal/uuidgen"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Rematch", func() {
+	var conn1, conn2 *testserver.TestPlayerInRoom
+
+	BeforeEach(func(ctx SpecContext) {
+		conn1, conn2 = createTwoPlayers(ctx)
+		joinSameTeam(ctx, "our team", conn1, conn2)
+
+		// matches need distinct IDs
+		uuidgen.SetGlobal(uuidgen.NewGoogleUUID())
+		DeferCleanup(func() {
+			uuidgen.SetGlobal(uuidgen.NewConstant(testserver.TestUUID))
+		})
+
+		startGame(ctx, conn1, conn2)
+		finishGame(ctx, 3, 1, conn1, conn2)
+	}, NodeTimeout(time.Second))
+
+	It("starts a linked match when players accept", func(ctx SpecContext) {
+		err := conn2.ProposeRematch(false, false)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(conn2.NextMsg(ctx).GetError()).ShouldNot(BeNil())
+
+		By("propose rematch")
+		err = conn1.ProposeRematch(false, false)
+		Expect(err).ShouldNot(HaveOccurred())
+		each(func(conn *testserver.TestPlayerInRoom) {
+			Expect(conn.NextMsg(ctx).GetRematchStatus()).Should(matcher.EqualCmp(&gamesvc.MsgRematchStatus{
+				ProposedBy:        conn1.ID(),
+				AcceptedPlayerIds: []string{conn1.ID()},
+				Quorum:            2,
+			}))
+		}, conn1, conn2)
+
+		By("accept rematch")
+		err = conn2.VoteRematch(true)
+		Expect(err).ShouldNot(HaveOccurred())
+		each(func(conn *testserver.TestPlayerInRoom) {
+			Expect(conn.NextMsg(ctx).GetRematchStatus().GetAcceptedPlayerIds()).Should(HaveLen(2))
+			Expect(conn.NextMsg(ctx).GetStartGame()).Should(matcher.EqualCmp(&gamesvc.MsgStartGame{
+				NextPlayerTurn: conn1.ID(),
+			}))
+		}, conn1, conn2)
+
+		finishGame(ctx, 2, 2, conn1, conn2)
+
+		By("fetch history")
+		var matches []*gamesvc.Match
+		Eventually(ctx, func(g Gomega) {
+			resp, err := conn1.Client().GetPlayerHistory(ctx, &gamesvc.GetPlayerHistoryRequest{
+				PlayerId: conn1.ID(),
+			})
+			g.Expect(err).ShouldNot(HaveOccurred())
+			g.Expect(resp.Matches).Should(HaveLen(2))
+
+			matches = resp.Matches
+		}).Should(Succeed())
+
+		rematch, original := matches[0], matches[1]
+		if rematch.RematchOf == "" {
+			rematch, original = original, rematch
+		}
+		Expect(rematch.RematchOf).Should(Equal(original.Id))
+		Expect(original.RematchOf).Should(BeEmpty())
+	}, NodeTimeout(time.Second))
+
+	It("swaps turn order", func(ctx SpecContext) {
+		err := conn1.ProposeRematch(true, false)
+		Expect(err).ShouldNot(HaveOccurred())
+		each(func(conn *testserver.TestPlayerInRoom) {
+			Expect(conn.NextMsg(ctx).GetRematchStatus().GetSwapTurnOrder()).Should(BeTrue())
+		}, conn1, conn2)
+
+		err = conn2.VoteRematch(true)
+		Expect(err).ShouldNot(HaveOccurred())
+		each(func(conn *testserver.TestPlayerInRoom) {
+			Expect(conn.NextMsg(ctx).GetRematchStatus()).ShouldNot(BeNil())
+			Expect(conn.NextMsg(ctx).GetStartGame().GetNextPlayerTurn()).Should(Equal(conn2.ID()))
+		}, conn1, conn2)
+	}, NodeTimeout(time.Second))
+
+	It("is rejected when the quorum declines", func(ctx SpecContext) {
+		err := conn1.ProposeRematch(false, false)
+		Expect(err).ShouldNot(HaveOccurred())
+		each(func(conn *testserver.TestPlayerInRoom) {
+			Expect(conn.NextMsg(ctx).GetRematchStatus()).ShouldNot(BeNil())
+		}, conn1, conn2)
+
+		err = conn2.VoteRematch(false)
+		Expect(err).ShouldNot(HaveOccurred())
+		each(func(conn *testserver.TestPlayerInRoom) {
+			status := conn.NextMsg(ctx).GetRematchStatus()
+			Expect(status.GetRejected()).Should(BeTrue())
+			Expect(status.GetDeclinedPlayerIds()).Should(Equal([]string{conn2.ID()}))
+		}, conn1, conn2)
+
+		err = conn2.VoteRematch(true)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(conn2.NextMsg(ctx).GetError().GetError()).Should(Equal("nobody proposed rematch"))
+	}, NodeTimeout(time.Second))
+})
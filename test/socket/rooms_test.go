@@ -0,0 +1,207 @@
+package socket_test
+
+import (
+	"time"
+
+	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	"github.com/knightpp/alias-server/internal/game"
+	"github.com/knightpp/alias-server/internal/testutil/testserver"
+	"github.com/knightpp/alias-server/internal/uuidgen"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var _ = Describe("Rooms", func() {
+	var (
+		opts    game.Options
+		srv     *testserver.TestServer
+		players []*testserver.TestPlayer
+	)
+
+	listRooms := func(ctx SpecContext) []*gamesvc.Room {
+		resp, err := players[0].Client().ListRooms(ctx, &gamesvc.ListRoomsRequest{})
+		Expect(err).ShouldNot(HaveOccurred())
+		return resp.Rooms
+	}
+
+	BeforeEach(func() {
+		// rooms need distinct IDs
+		uuidgen.SetGlobal(uuidgen.NewGoogleUUID())
+		DeferCleanup(func() {
+			uuidgen.SetGlobal(uuidgen.NewConstant(testserver.TestUUID))
+		})
+
+		opts = game.DefaultOptions()
+		opts.Rooms.JanitorInterval = 20 * time.Millisecond
+	})
+
+	JustBeforeEach(func(ctx SpecContext) {
+		var err error
+		srv, err = testserver.CreateAndStartWithOptions(testserver.Options{
+			Game: &opts,
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		players = srv.CreatePlayers(ctx, 2, protoPlayer)
+	}, NodeTimeout(time.Second))
+
+	Context("with limits", func() {
+		BeforeEach(func() {
+			opts.Rooms.Max = 3
+			opts.Rooms.MaxPerPlayer = 2
+		})
+
+		It("limits rooms of a player and of the server", func(ctx SpecContext) {
+			for i := 0; i < 2; i++ {
+				_, err := players[0].CreateRoom(ctx, protoRoom())
+				Expect(err).ShouldNot(HaveOccurred())
+			}
+
+			_, err := players[0].CreateRoom(ctx, protoRoom())
+			Expect(status.Code(err)).Should(Equal(codes.ResourceExhausted))
+
+			_, err = players[1].CreateRoom(ctx, protoRoom())
+			Expect(err).ShouldNot(HaveOccurred())
+
+			_, err = players[1].CreateRoom(ctx, protoRoom())
+			Expect(status.Code(err)).Should(Equal(codes.ResourceExhausted))
+		}, NodeTimeout(time.Second))
+	})
+
+	Context("when listed", func() {
+		listRoomIDs := func(ctx SpecContext, req *gamesvc.ListRoomsRequest) ([]string, string) {
+			resp, err := players[0].Client().ListRooms(ctx, req)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			ids := make([]string, len(resp.Rooms))
+			for i, r := range resp.Rooms {
+				ids[i] = r.Id
+			}
+			return ids, resp.NextPageToken
+		}
+
+		It("filters rooms by language and status", func(ctx SpecContext) {
+			uaRoomID, err := players[0].CreateRoom(ctx, protoRoom())
+			Expect(err).ShouldNot(HaveOccurred())
+
+			enRoom := protoRoom()
+			enRoom.Langugage = "EN"
+			enRoomID, err := players[1].CreateRoom(ctx, enRoom)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			ids, _ := listRoomIDs(ctx, &gamesvc.ListRoomsRequest{Langugage: "EN"})
+			Expect(ids).Should(Equal([]string{enRoomID}))
+
+			ids, _ = listRoomIDs(ctx, &gamesvc.ListRoomsRequest{
+				Statuses: []gamesvc.RoomStatus{gamesvc.RoomStatus_ROOM_STATUS_LOBBY},
+			})
+			Expect(ids).Should(Equal([]string{uaRoomID, enRoomID}))
+
+			ids, _ = listRoomIDs(ctx, &gamesvc.ListRoomsRequest{
+				Statuses: []gamesvc.RoomStatus{gamesvc.RoomStatus_ROOM_STATUS_IN_GAME},
+			})
+			Expect(ids).Should(BeEmpty())
+		}, NodeTimeout(time.Second))
+
+		It("pages rooms", func(ctx SpecContext) {
+			var created []string
+			for _, player := range players {
+				for i := 0; i < 2; i++ {
+					roomID, err := player.CreateRoom(ctx, protoRoom())
+					Expect(err).ShouldNot(HaveOccurred())
+					created = append(created, roomID)
+				}
+			}
+
+			first, token := listRoomIDs(ctx, &gamesvc.ListRoomsRequest{PageSize: 3})
+			Expect(first).Should(Equal(created[:3]))
+			Expect(token).ShouldNot(BeEmpty())
+
+			second, token := listRoomIDs(ctx, &gamesvc.ListRoomsRequest{PageSize: 3, PageToken: token})
+			Expect(second).Should(Equal(created[3:]))
+			Expect(token).Should(BeEmpty())
+
+			_, err := players[0].Client().ListRooms(ctx, &gamesvc.ListRoomsRequest{PageToken: "page"})
+			Expect(status.Code(err)).Should(Equal(codes.InvalidArgument))
+		}, NodeTimeout(time.Second))
+
+		It("shows players, capacity and password", func(ctx SpecContext) {
+			room := protoRoom()
+			password := "secret"
+			room.Password = &password
+			roomID, err := players[0].CreateRoom(ctx, room)
+			Expect(err).ShouldNot(HaveOccurred())
+			conns := srv.JoinPlayers(ctx, roomID, players...)
+
+			err = conns[0].CreateTeam("team")
+			Expect(err).ShouldNot(HaveOccurred())
+			team := conns[0].NextMsg(ctx).GetTeamCreated().GetTeam()
+			Expect(team).ShouldNot(BeNil())
+			Expect(conns[1].NextMsg(ctx).GetTeamCreated()).ShouldNot(BeNil())
+
+			err = conns[0].JoinTeam(team.Id)
+			Expect(err).ShouldNot(HaveOccurred())
+			each(func(conn *testserver.TestPlayerInRoom) {
+				Expect(conn.NextMsg(ctx).GetUpdateRoom()).ShouldNot(BeNil())
+			}, conns...)
+
+			// the player in the lobby doesn't take a slot
+			rooms := listRooms(ctx)
+			Expect(rooms).Should(HaveLen(1))
+			Expect(rooms[0].Status).Should(Equal(gamesvc.RoomStatus_ROOM_STATUS_LOBBY))
+			Expect(rooms[0].PlayerCount).Should(BeEquivalentTo(1))
+			Expect(rooms[0].Capacity).Should(BeEquivalentTo(2))
+			Expect(rooms[0].HasPassword).Should(BeTrue())
+		}, NodeTimeout(time.Second))
+	})
+
+	Context("with unjoined TTL", func() {
+		BeforeEach(func() {
+			opts.Rooms.MaxPerPlayer = 1
+			opts.Rooms.UnjoinedTTL = 100 * time.Millisecond
+		})
+
+		It("closes rooms nobody joined", func(ctx SpecContext) {
+			_, err := players[0].CreateRoom(ctx, protoRoom())
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(listRooms(ctx)).Should(HaveLen(1))
+
+			Eventually(ctx, listRooms).Should(BeEmpty())
+
+			_, err = players[0].CreateRoom(ctx, protoRoom())
+			Expect(err).ShouldNot(HaveOccurred())
+		}, NodeTimeout(time.Second))
+
+		It("keeps joined rooms", func(ctx SpecContext) {
+			roomID, err := players[0].CreateRoom(ctx, protoRoom())
+			Expect(err).ShouldNot(HaveOccurred())
+			srv.JoinPlayers(ctx, roomID, players[0])
+
+			Consistently(ctx, listRooms, 200*time.Millisecond).Should(HaveLen(1))
+		}, NodeTimeout(time.Second))
+	})
+
+	Context("with idle TTL", func() {
+		BeforeEach(func() {
+			opts.Player.Heartbeat = 0
+			opts.IdleTimeout = 0
+			opts.Rooms.IdleTTL = 150 * time.Millisecond
+		})
+
+		It("closes rooms where nothing happens", func(ctx SpecContext) {
+			roomID, err := players[0].CreateRoom(ctx, protoRoom())
+			Expect(err).ShouldNot(HaveOccurred())
+			conns := srv.JoinPlayers(ctx, roomID, players...)
+
+			each(func(conn *testserver.TestPlayerInRoom) {
+				notice := conn.NextMsg(ctx).GetServerNotice()
+				Expect(notice.GetText()).Should(Equal("The room was closed for inactivity"))
+				Eventually(ctx, conn.Closed()).Should(BeClosed())
+			}, conns...)
+
+			Eventually(ctx, listRooms).Should(BeEmpty())
+		}, NodeTimeout(time.Second))
+	})
+})
@@ -7,6 +7,9 @@ import (
 	"github.com/knightpp/alias-server/internal/uuidgen"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 func TestSocket(t *testing.T) {
@@ -14,6 +17,10 @@ func TestSocket(t *testing.T) {
 	RunSpecs(t, "Socket Suite")
 }
 
+// spans has every span finished during the suite.
+var spans = tracetest.NewInMemoryExporter()
+
 var _ = BeforeSuite(func() {
 	uuidgen.SetGlobal(uuidgen.NewConstant(testserver.TestUUID))
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(spans)))
 })
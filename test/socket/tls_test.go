@@ -0,0 +1,169 @@
+package socket_test
+
+import (
+	"context"
+	"crypto/tls"
+	"os"
+	"path/filepath"
+	"time"
+
+	adminsvc "github.com/knightpp/alias-proto/go/admin_service"
+	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	"github.com/knightpp/alias-server/internal/testutil/certs"
+	"github.com/knightpp/alias-server/internal/testutil/testserver"
+	"github.com/knightpp/alias-server/internal/tlsconfig"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+var _ = Describe("TLS", func() {
+	var (
+		ca       *certs.CA
+		opts     tlsconfig.Options
+		reloader *tlsconfig.Reloader
+		srv      *testserver.TestServer
+	)
+
+	writePair := func(pair certs.Pair) {
+		Expect(os.WriteFile(opts.CertFile, pair.CertPEM, 0o600)).Should(Succeed())
+		Expect(os.WriteFile(opts.KeyFile, pair.KeyPEM, 0o600)).Should(Succeed())
+	}
+
+	// serverName returns common name of the certificate the server presents
+	serverName := func() string {
+		conn, err := tls.Dial("tcp", srv.Addr(), &tls.Config{
+			RootCAs:    ca.Pool(),
+			NextProtos: []string{"h2"},
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+		defer conn.Close()
+
+		return conn.ConnectionState().PeerCertificates[0].Subject.CommonName
+	}
+
+	BeforeEach(func() {
+		var err error
+		ca, err = certs.NewCA()
+		Expect(err).ShouldNot(HaveOccurred())
+
+		dir := GinkgoT().TempDir()
+		opts = tlsconfig.DefaultOptions(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"))
+		opts.ClientCAFile = filepath.Join(dir, "ca.pem")
+		opts.ReloadInterval = 10 * time.Millisecond
+		Expect(os.WriteFile(opts.ClientCAFile, ca.PEM(), 0o600)).Should(Succeed())
+
+		pair, err := ca.Server("first")
+		Expect(err).ShouldNot(HaveOccurred())
+		writePair(pair)
+
+		reloader, err = tlsconfig.NewReloader(zerolog.Nop(), opts)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		serverTLS, err := reloader.Config()
+		Expect(err).ShouldNot(HaveOccurred())
+
+		srv, err = testserver.CreateAndStartWithOptions(testserver.Options{
+			TLS:             serverTLS,
+			ClientTLS:       &tls.Config{RootCAs: ca.Pool()},
+			AdminClientCert: true,
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+
+	It("serves players over TLS", func(ctx SpecContext) {
+		player, err := srv.NewPlayer(ctx, protoPlayer(1))
+		Expect(err).ShouldNot(HaveOccurred())
+
+		_, err = player.Client().ListRooms(ctx, &gamesvc.ListRoomsRequest{})
+		Expect(err).ShouldNot(HaveOccurred())
+	}, NodeTimeout(time.Second))
+
+	It("doesn't serve plaintext", func(ctx SpecContext) {
+		conn, err := grpc.DialContext(ctx, srv.Addr(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+		Expect(err).ShouldNot(HaveOccurred())
+		DeferCleanup(conn.Close)
+
+		_, err = gamesvc.NewGameServiceClient(conn).ListRooms(ctx, &gamesvc.ListRoomsRequest{})
+		Expect(status.Code(err)).Should(Equal(codes.Unavailable))
+	}, NodeTimeout(time.Second))
+
+	It("reloads the certificate when files change", func(ctx SpecContext) {
+		Expect(serverName()).Should(Equal("first"))
+
+		reloaderCtx, cancel := context.WithCancel(ctx)
+		DeferCleanup(cancel)
+		go reloader.Start(reloaderCtx)
+
+		pair, err := ca.Server("second")
+		Expect(err).ShouldNot(HaveOccurred())
+		writePair(pair)
+
+		Eventually(ctx, serverName).Should(Equal("second"))
+	}, NodeTimeout(time.Second))
+
+	It("keeps the certificate if files are invalid", func() {
+		Expect(os.WriteFile(opts.KeyFile, []byte("invalid"), 0o600)).Should(Succeed())
+
+		_, err := reloader.Reload()
+		Expect(err).Should(HaveOccurred())
+		Expect(serverName()).Should(Equal("first"))
+	})
+
+	Context("admin service", func() {
+		adminClient := func(ctx SpecContext, clientTLS *tls.Config) adminsvc.AdminServiceClient {
+			conn, err := grpc.DialContext(ctx, srv.Addr(), grpc.WithTransportCredentials(credentials.NewTLS(clientTLS)))
+			Expect(err).ShouldNot(HaveOccurred())
+			DeferCleanup(conn.Close)
+
+			return adminsvc.NewAdminServiceClient(conn)
+		}
+
+		It("requires a client certificate", func(ctx SpecContext) {
+			admin := adminClient(ctx, &tls.Config{RootCAs: ca.Pool()})
+
+			_, err := admin.ListRooms(testserver.AdminContext(ctx), &adminsvc.ListRoomsRequest{})
+			Expect(status.Code(err)).Should(Equal(codes.Unauthenticated))
+		}, NodeTimeout(time.Second))
+
+		It("accepts a client certificate", func(ctx SpecContext) {
+			pair, err := ca.Client("operator")
+			Expect(err).ShouldNot(HaveOccurred())
+
+			cert, err := pair.TLS()
+			Expect(err).ShouldNot(HaveOccurred())
+
+			admin := adminClient(ctx, &tls.Config{
+				RootCAs:      ca.Pool(),
+				Certificates: []tls.Certificate{cert},
+			})
+
+			_, err = admin.ListRooms(testserver.AdminContext(ctx), &adminsvc.ListRoomsRequest{})
+			Expect(err).ShouldNot(HaveOccurred())
+		}, NodeTimeout(time.Second))
+
+		It("rejects a certificate of another CA", func(ctx SpecContext) {
+			otherCA, err := certs.NewCA()
+			Expect(err).ShouldNot(HaveOccurred())
+
+			pair, err := otherCA.Client("intruder")
+			Expect(err).ShouldNot(HaveOccurred())
+
+			cert, err := pair.TLS()
+			Expect(err).ShouldNot(HaveOccurred())
+
+			admin := adminClient(ctx, &tls.Config{
+				RootCAs:      ca.Pool(),
+				Certificates: []tls.Certificate{cert},
+			})
+
+			_, err = admin.ListRooms(testserver.AdminContext(ctx), &adminsvc.ListRoomsRequest{})
+			Expect(err).Should(HaveOccurred())
+		}, NodeTimeout(time.Second))
+	})
+})
@@ -0,0 +1,102 @@
+package socket_test
+
+import (
+	"time"
+
+	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	"github.com/knightpp/alias-server/internal/testutil/testserver"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var _ = Describe("Tracing", func() {
+	var conn1, conn2 *testserver.TestPlayerInRoom
+
+	BeforeEach(func(ctx SpecContext) {
+		conn1, conn2 = createTwoPlayers(ctx)
+		spans.Reset()
+	}, NodeTimeout(time.Second))
+
+	findSpans := func(name string, attrs ...attribute.KeyValue) []tracetest.SpanStub {
+		var found []tracetest.SpanStub
+	spans:
+		for _, span := range spans.GetSpans() {
+			if span.Name != name {
+				continue
+			}
+
+			for _, attr := range attrs {
+				var ok bool
+				for _, spanAttr := range span.Attributes {
+					if spanAttr == attr {
+						ok = true
+						break
+					}
+				}
+				if !ok {
+					continue spans
+				}
+			}
+
+			found = append(found, span)
+		}
+		return found
+	}
+
+	It("follows a message through the room", func(ctx SpecContext) {
+		err := conn1.Chat("hello")
+		Expect(err).ShouldNot(HaveOccurred())
+		each(func(conn *testserver.TestPlayerInRoom) {
+			Expect(conn.NextMsg(ctx).GetChat()).ShouldNot(BeNil())
+		}, conn1, conn2)
+
+		chat := attribute.String("message.type", "chat")
+
+		Eventually(func() []tracetest.SpanStub {
+			return findSpans("player.send", chat)
+		}).Should(HaveLen(2))
+
+		received := findSpans("player.receive", chat, attribute.String("player.id", conn1.ID()))
+		Expect(received).Should(HaveLen(1))
+		// the span of Join stream is the parent
+		Expect(received[0].Parent.IsValid()).Should(BeTrue())
+		Expect(received[0].Parent.IsRemote()).Should(BeFalse())
+
+		handled := findSpans("room.handle_message", chat, attribute.String("room.state", "Lobby"))
+		Expect(handled).Should(HaveLen(1))
+		Expect(handled[0].Parent.SpanID()).Should(Equal(received[0].SpanContext.SpanID()))
+
+		for _, sent := range findSpans("player.send", chat) {
+			Expect(sent.Parent.SpanID()).Should(Equal(handled[0].SpanContext.SpanID()))
+			Expect(sent.SpanContext.TraceID()).Should(Equal(received[0].SpanContext.TraceID()))
+		}
+	}, NodeTimeout(time.Second))
+
+	It("marks failed messages", func(ctx SpecContext) {
+		err := conn1.StartTurn(time.Minute)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(conn1.NextMsg(ctx).GetError()).ShouldNot(BeNil())
+
+		Eventually(func() []tracetest.SpanStub {
+			return findSpans("player.send", attribute.String("message.type", "error"))
+		}).Should(HaveLen(1))
+
+		handled := findSpans("room.handle_message", attribute.String("message.type", "start_turn"))
+		Expect(handled).Should(HaveLen(1))
+		Expect(handled[0].Status.Code.String()).Should(Equal("Error"))
+		Expect(handled[0].Events).ShouldNot(BeEmpty())
+	}, NodeTimeout(time.Second))
+
+	It("traces RPCs", func(ctx SpecContext) {
+		_, err := conn1.Client().ListRooms(ctx, &gamesvc.ListRoomsRequest{})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		Eventually(func() []tracetest.SpanStub {
+			return findSpans("game_service.GameService/ListRooms")
+		}).Should(HaveLen(1))
+		Expect(findSpans("game_service.GameService/ListRooms")[0].SpanKind).Should(Equal(trace.SpanKindServer))
+	}, NodeTimeout(time.Second))
+})
@@ -3,6 +3,7 @@ package socket_test
 import (
 	"context"
 	"strconv"
+	"time"
 
 	gamesvc "github.com/knightpp/alias-proto/go/game_service"
 	"github.com/knightpp/alias-server/internal/testutil/factory"
@@ -110,3 +111,70 @@ func joinSameTeam(
 	Expect(conn2.NextMsg(ctx)).Should(matcher.EqualCmp(roomMsg))
 	return teamID
 }
+
+// startTeamGame puts both players into one team and starts a game where
+// conn1 explains first.
+func startTeamGame(
+	ctx context.Context,
+	teamName string,
+	conn1, conn2 *testserver.TestPlayerInRoom,
+) (teamID string) {
+	teamID = joinSameTeam(ctx, teamName, conn1, conn2)
+	startGame(ctx, conn1, conn2)
+	return teamID
+}
+
+// startGame starts a game where the explainer has the first turn.
+func startGame(
+	ctx context.Context,
+	explainer *testserver.TestPlayerInRoom,
+	others ...*testserver.TestPlayerInRoom,
+) {
+	By("start game")
+	err := explainer.StartGame(explainer.ID())
+	Expect(err).ShouldNot(HaveOccurred())
+	each(func(conn *testserver.TestPlayerInRoom) {
+		Expect(conn.NextMsg(ctx).GetStartGame()).ShouldNot(BeNil())
+	}, append([]*testserver.TestPlayerInRoom{explainer}, others...)...)
+}
+
+// startTurn starts a minute long turn of the explainer. The game must be
+// already started with explainer's turn.
+func startTurn(
+	ctx context.Context,
+	explainer *testserver.TestPlayerInRoom,
+	others ...*testserver.TestPlayerInRoom,
+) {
+	By("start turn")
+	err := explainer.StartTurn(time.Minute)
+	Expect(err).ShouldNot(HaveOccurred())
+	each(func(conn *testserver.TestPlayerInRoom) {
+		Expect(conn.NextMsg(ctx).GetStartTurn()).ShouldNot(BeNil())
+	}, append([]*testserver.TestPlayerInRoom{explainer}, others...)...)
+}
+
+// finishGame plays a single turn of the explainer and ends the game. The game
+// must be already started with explainer's turn.
+func finishGame(
+	ctx context.Context,
+	rights, wrongs uint32,
+	explainer *testserver.TestPlayerInRoom,
+	others ...*testserver.TestPlayerInRoom,
+) {
+	all := append([]*testserver.TestPlayerInRoom{explainer}, others...)
+
+	startTurn(ctx, explainer, others...)
+
+	err := explainer.EndTurn(rights, wrongs)
+	Expect(err).ShouldNot(HaveOccurred())
+	each(func(conn *testserver.TestPlayerInRoom) {
+		Expect(conn.NextMsg(ctx).GetEndTurn()).ShouldNot(BeNil())
+	}, others...)
+
+	By("end game")
+	err = explainer.EndGame()
+	Expect(err).ShouldNot(HaveOccurred())
+	each(func(conn *testserver.TestPlayerInRoom) {
+		Expect(conn.NextMsg(ctx).GetResults()).ShouldNot(BeNil())
+	}, all...)
+}
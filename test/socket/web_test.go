@@ -0,0 +1,157 @@
+package socket_test
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	"github.com/knightpp/alias-proto/go/mdkey"
+	"github.com/knightpp/alias-server/internal/testutil/grpcweb"
+	"github.com/knightpp/alias-server/internal/testutil/matcher"
+	"github.com/knightpp/alias-server/internal/testutil/testserver"
+	"github.com/knightpp/alias-server/internal/web"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var _ = Describe("Web", func() {
+	const origin = "https://alias.example"
+
+	var (
+		srv     *testserver.TestServer
+		client  *grpcweb.Client
+		players []*testserver.TestPlayer
+		roomID  string
+	)
+
+	method := func(name string) string {
+		return "/" + gamesvc.GameService_ServiceDesc.ServiceName + "/" + name
+	}
+
+	BeforeEach(func(ctx SpecContext) {
+		var err error
+		srv, err = testserver.CreateAndStartWithOptions(testserver.Options{
+			Web: &web.Options{AllowedOrigins: []string{origin}},
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		client = grpcweb.NewClient(srv.URL())
+		players = srv.CreatePlayers(ctx, 2, protoPlayer)
+
+		roomID, err = players[0].CreateRoom(ctx, protoRoom())
+		Expect(err).ShouldNot(HaveOccurred())
+	}, NodeTimeout(time.Second))
+
+	It("serves unary requests", func(ctx SpecContext) {
+		var resp gamesvc.ListRoomsResponse
+		err := client.Invoke(ctx, method("ListRooms"), nil, &gamesvc.ListRoomsRequest{}, &resp)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(resp.Rooms).Should(HaveLen(1))
+		Expect(resp.Rooms[0].Id).Should(Equal(roomID))
+	}, NodeTimeout(time.Second))
+
+	It("still serves gRPC", func(ctx SpecContext) {
+		resp, err := players[0].Client().ListRooms(ctx, &gamesvc.ListRoomsRequest{})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(resp.Rooms).Should(HaveLen(1))
+	}, NodeTimeout(time.Second))
+
+	It("allows cross-origin requests from allowed origins", func(ctx SpecContext) {
+		preflight := func(origin string) *http.Response {
+			req, err := http.NewRequestWithContext(ctx, http.MethodOptions, srv.URL()+method("ListRooms"), nil)
+			Expect(err).ShouldNot(HaveOccurred())
+			req.Header.Set("Origin", origin)
+			req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			req.Header.Set("Access-Control-Request-Headers", "content-type,x-grpc-web,x-user-agent,token")
+
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).ShouldNot(HaveOccurred())
+			resp.Body.Close()
+			return resp
+		}
+
+		resp := preflight(origin)
+		Expect(resp.Header.Get("Access-Control-Allow-Origin")).Should(Equal(origin))
+
+		resp = preflight("https://evil.example")
+		Expect(resp.Header.Get("Access-Control-Allow-Origin")).Should(BeEmpty())
+	}, NodeTimeout(time.Second))
+
+	Context("JoinStream", func() {
+		var (
+			conn1  *testserver.TestPlayerInRoom
+			webMD  metadata.MD
+			stream *grpcweb.Stream
+		)
+
+		nextMsg := func() *gamesvc.Message {
+			var msg gamesvc.Message
+			Expect(stream.Recv(&msg)).Should(Succeed())
+			return &msg
+		}
+
+		BeforeEach(func(ctx SpecContext) {
+			conn1 = srv.JoinPlayers(ctx, roomID, players[0])[0]
+
+			webMD = metadata.Pairs(mdkey.Auth, players[1].Token())
+
+			// the stream outlives the setup
+			streamCtx, cancel := context.WithCancel(context.Background())
+			DeferCleanup(cancel)
+
+			var err error
+			stream, err = client.Stream(streamCtx, method("JoinStream"), webMD, &gamesvc.JoinStreamRequest{
+				RoomId: roomID,
+			})
+			Expect(err).ShouldNot(HaveOccurred())
+			DeferCleanup(stream.Close)
+
+			Expect(nextMsg().GetUpdateRoom().GetRoom().GetLobby()).Should(HaveLen(2))
+			Expect(conn1.NextMsg(ctx).GetUpdateRoom().GetRoom().GetLobby()).Should(HaveLen(2))
+		}, NodeTimeout(time.Second))
+
+		It("sends messages of the web player to the room", func(ctx SpecContext) {
+			chat := &gamesvc.Message{
+				Message: &gamesvc.Message_Chat{
+					Chat: &gamesvc.MsgChat{Text: "hello"},
+				},
+			}
+
+			var resp gamesvc.SendMessageResponse
+			err := client.Invoke(ctx, method("SendMessage"), webMD, &gamesvc.SendMessageRequest{
+				RoomId:  roomID,
+				Message: chat,
+			}, &resp)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			chat.GetChat().PlayerId = players[1].Proto().Id
+			Expect(conn1.NextMsg(ctx)).Should(matcher.EqualCmp(chat))
+			Expect(nextMsg()).Should(matcher.EqualCmp(chat))
+		}, NodeTimeout(time.Second))
+
+		It("doesn't join the same room twice", func(ctx SpecContext) {
+			second, err := client.Stream(ctx, method("JoinStream"), webMD, &gamesvc.JoinStreamRequest{
+				RoomId: roomID,
+			})
+			if err == nil {
+				DeferCleanup(second.Close)
+				var msg gamesvc.Message
+				err = second.Recv(&msg)
+			}
+			Expect(status.Code(err)).Should(Equal(codes.AlreadyExists))
+		}, NodeTimeout(time.Second))
+	})
+
+	It("doesn't send messages before JoinStream", func(ctx SpecContext) {
+		var resp gamesvc.SendMessageResponse
+		err := client.Invoke(ctx, method("SendMessage"), metadata.Pairs(mdkey.Auth, players[1].Token()), &gamesvc.SendMessageRequest{
+			RoomId:  roomID,
+			Message: &gamesvc.Message{},
+		}, &resp)
+		Expect(status.Code(err)).Should(Equal(codes.FailedPrecondition))
+	}, NodeTimeout(time.Second))
+})
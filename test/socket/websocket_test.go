@@ -0,0 +1,154 @@
+package socket_test
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	gamesvc "github.com/knightpp/alias-proto/go/game_service"
+	"github.com/knightpp/alias-proto/go/mdkey"
+	"github.com/knightpp/alias-server/internal/ratelimit"
+	"github.com/knightpp/alias-server/internal/testutil/matcher"
+	"github.com/knightpp/alias-server/internal/testutil/testserver"
+	"github.com/knightpp/alias-server/internal/web"
+	"github.com/knightpp/alias-server/internal/wsgateway"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/encoding/protojson"
+	"nhooyr.io/websocket"
+)
+
+var _ = Describe("WebSocket", func() {
+	const joinBurst = 3
+
+	var (
+		srv     *testserver.TestServer
+		players []*testserver.TestPlayer
+		roomID  string
+		conn1   *testserver.TestPlayerInRoom
+	)
+
+	dial := func(ctx context.Context, token string) (*websocket.Conn, error) {
+		query := url.Values{}
+		query.Set(mdkey.RoomID, roomID)
+
+		wsURL := strings.Replace(srv.URL(), "http", "ws", 1) + "/ws/join?" + query.Encode()
+		ws, _, err := websocket.Dial(ctx, wsURL, &websocket.DialOptions{
+			Subprotocols: []string{wsgateway.Subprotocol, wsgateway.AuthSubprotocolPrefix + token},
+		})
+		if err == nil {
+			// specs may have closed it already
+			DeferCleanup(func() { _ = ws.CloseNow() })
+		}
+		return ws, err
+	}
+
+	read := func(ctx context.Context, ws *websocket.Conn) *gamesvc.Message {
+		typ, data, err := ws.Read(ctx)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(typ).Should(Equal(websocket.MessageText))
+
+		var msg gamesvc.Message
+		Expect(protojson.Unmarshal(data, &msg)).Should(Succeed())
+		return &msg
+	}
+
+	BeforeEach(func(ctx SpecContext) {
+		var err error
+		limitOpts := ratelimit.DefaultOptions()
+		limitOpts.Methods["/"+gamesvc.GameService_ServiceDesc.ServiceName+"/Join"] = ratelimit.Every(time.Minute, joinBurst)
+		srv, err = testserver.CreateAndStartWithOptions(testserver.Options{
+			Web:       &web.Options{},
+			RateLimit: &limitOpts,
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		players = srv.CreatePlayers(ctx, 2, protoPlayer)
+
+		roomID, err = players[0].CreateRoom(ctx, protoRoom())
+		Expect(err).ShouldNot(HaveOccurred())
+
+		conn1 = srv.JoinPlayers(ctx, roomID, players[0])[0]
+	}, NodeTimeout(time.Second))
+
+	Context("joined", func() {
+		var ws *websocket.Conn
+
+		BeforeEach(func(ctx SpecContext) {
+			// the socket outlives the setup
+			wsCtx, cancel := context.WithCancel(context.Background())
+			DeferCleanup(cancel)
+
+			var err error
+			ws, err = dial(wsCtx, players[1].Token())
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Expect(read(ctx, ws).GetUpdateRoom().GetRoom().GetLobby()).Should(HaveLen(2))
+			Expect(conn1.NextMsg(ctx).GetUpdateRoom().GetRoom().GetLobby()).Should(HaveLen(2))
+		}, NodeTimeout(time.Second))
+
+		It("agrees on the subprotocol", func() {
+			Expect(ws.Subprotocol()).Should(Equal(wsgateway.Subprotocol))
+		})
+
+		It("translates JSON messages", func(ctx SpecContext) {
+			err := ws.Write(ctx, websocket.MessageText, []byte(`{"chat": {"text": "hello"}}`))
+			Expect(err).ShouldNot(HaveOccurred())
+
+			chat := &gamesvc.Message{
+				Message: &gamesvc.Message_Chat{
+					Chat: &gamesvc.MsgChat{
+						PlayerId: players[1].Proto().Id,
+						Text:     "hello",
+					},
+				},
+			}
+			Expect(conn1.NextMsg(ctx)).Should(matcher.EqualCmp(chat))
+			Expect(read(ctx, ws)).Should(matcher.EqualCmp(chat))
+		}, NodeTimeout(time.Second))
+
+		It("answers a malformed message with an error", func(ctx SpecContext) {
+			err := ws.Write(ctx, websocket.MessageText, []byte(`{"chat":`))
+			Expect(err).ShouldNot(HaveOccurred())
+
+			Expect(read(ctx, ws).GetError().GetError()).Should(HavePrefix("invalid message"))
+
+			err = ws.Write(ctx, websocket.MessageText, []byte(`{"chat": {"text": "still here"}}`))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(read(ctx, ws).GetChat().GetText()).Should(Equal("still here"))
+		}, NodeTimeout(time.Second))
+
+		It("leaves the room when the socket is closed", func(ctx SpecContext) {
+			Expect(ws.Close(websocket.StatusNormalClosure, "")).Should(Succeed())
+
+			update := conn1.NextMsg(ctx).GetUpdateRoom()
+			Expect(update.GetRoom().GetLobby()).Should(HaveLen(1))
+		}, NodeTimeout(time.Second))
+	})
+
+	It("closes the socket of an unauthenticated player", func(ctx SpecContext) {
+		ws, err := dial(ctx, "unknown")
+		Expect(err).ShouldNot(HaveOccurred())
+
+		_, _, err = ws.Read(ctx)
+		Expect(websocket.CloseStatus(err)).Should(BeEquivalentTo(wsgateway.CloseCodeOffset + int(codes.Unauthenticated)))
+	}, NodeTimeout(time.Second))
+
+	It("rate limits joins like the gRPC server", func(ctx SpecContext) {
+		for i := 0; i < joinBurst; i++ {
+			ws, err := dial(ctx, "unknown")
+			Expect(err).ShouldNot(HaveOccurred())
+
+			_, _, err = ws.Read(ctx)
+			Expect(websocket.CloseStatus(err)).Should(BeEquivalentTo(wsgateway.CloseCodeOffset + int(codes.Unauthenticated)))
+		}
+
+		ws, err := dial(ctx, "unknown")
+		Expect(err).ShouldNot(HaveOccurred())
+
+		_, _, err = ws.Read(ctx)
+		Expect(websocket.CloseStatus(err)).Should(BeEquivalentTo(wsgateway.CloseCodeOffset + int(codes.ResourceExhausted)))
+	}, NodeTimeout(time.Second))
+})